@@ -0,0 +1,502 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"unicode/utf16"
+)
+
+// The block keys of the agile encryption (MS-OFFCRYPTO 2.3.4.13).
+var (
+	verifierHashInputBlockKey = []byte{0xfe, 0xa7, 0xd2, 0x76, 0x3b, 0x4b, 0x9e, 0x79}
+	verifierHashValueBlockKey = []byte{0xd7, 0xaa, 0x0f, 0x6d, 0x30, 0x61, 0x34, 0x4e}
+	encryptedKeyValueBlockKey = []byte{0x14, 0x6e, 0x0b, 0xe7, 0xab, 0xac, 0xd0, 0xd6}
+)
+
+const (
+	// agileSegmentSize is the size of the segments the package is encrypted
+	// in by the agile encryption.
+	agileSegmentSize = 4096
+	// standardSpinCount is the number of hashing rounds of the key derivation
+	// of the standard encryption.
+	standardSpinCount = 50000
+	// maxSpinCount is the largest number of hashing rounds the agile
+	// encryption allows.
+	maxSpinCount = 10000000
+)
+
+// The algorithm ids of the standard encryption header.
+const (
+	algorithmAes128 = 0x660e
+	algorithmAes192 = 0x660f
+	algorithmAes256 = 0x6610
+	algorithmSha1   = 0x8004
+)
+
+// standardKeySizes maps the algorithm ids of the standard encryption to the
+// size of their keys in bytes.
+var standardKeySizes = map[uint32]int{
+	algorithmAes128: 16,
+	algorithmAes192: 24,
+	algorithmAes256: 32,
+}
+
+// encryptedError is the error of encrypted documents that cannot be
+// decrypted.
+func encryptedError(message string) error {
+	return &Error{Kind: ErrEncrypted, Message: fmt.Sprintf("The document cannot be decrypted: %s", message)}
+}
+
+// wrongPasswordError is the error of encrypted documents given with a wrong
+// password.
+func wrongPasswordError() error {
+	return &Error{Kind: ErrWrongPassword, Message: "The password of the document is wrong"}
+}
+
+// decryptPackage returns the OOXML package stored in the encrypted document
+// (an OLE compound file with EncryptionInfo and EncryptedPackage streams).
+// Agile and standard (AES) encryption are supported.
+func decryptPackage(reader io.ReaderAt, size int64, password string) ([]byte, error) {
+	ole, err := readOleFile(reader, size)
+	if err != nil {
+		return nil, err
+	}
+
+	info, found, err := ole.stream("EncryptionInfo")
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, encryptedError("missing EncryptionInfo stream")
+	}
+
+	encrypted, found, err := ole.stream("EncryptedPackage")
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, encryptedError("missing EncryptedPackage stream")
+	}
+
+	if len(info) < 8 || len(encrypted) < 8 {
+		return nil, encryptedError("truncated stream")
+	}
+
+	major := binary.LittleEndian.Uint16(info)
+	minor := binary.LittleEndian.Uint16(info[2:])
+
+	switch {
+	case major == 4 && minor == 4:
+		return decryptAgile(info[8:], encrypted, password)
+	case (major == 2 || major == 3 || major == 4) && minor == 2:
+		return decryptStandard(info[4:], encrypted, password)
+	}
+
+	return nil, encryptedError(fmt.Sprintf("unsupported encryption version %d.%d", major, minor))
+}
+
+// agileEncryption is the XML descriptor of the agile encryption.
+type agileEncryption struct {
+	KeyData struct {
+		SaltValue       string `xml:"saltValue,attr"`
+		BlockSize       int    `xml:"blockSize,attr"`
+		KeyBits         int    `xml:"keyBits,attr"`
+		HashAlgorithm   string `xml:"hashAlgorithm,attr"`
+		CipherAlgorithm string `xml:"cipherAlgorithm,attr"`
+		CipherChaining  string `xml:"cipherChaining,attr"`
+	} `xml:"keyData"`
+	KeyEncryptors []struct {
+		Uri          string `xml:"uri,attr"`
+		EncryptedKey *struct {
+			SpinCount                  int    `xml:"spinCount,attr"`
+			SaltValue                  string `xml:"saltValue,attr"`
+			KeyBits                    int    `xml:"keyBits,attr"`
+			HashAlgorithm              string `xml:"hashAlgorithm,attr"`
+			CipherAlgorithm            string `xml:"cipherAlgorithm,attr"`
+			CipherChaining             string `xml:"cipherChaining,attr"`
+			EncryptedVerifierHashInput string `xml:"encryptedVerifierHashInput,attr"`
+			EncryptedVerifierHashValue string `xml:"encryptedVerifierHashValue,attr"`
+			EncryptedKeyValue          string `xml:"encryptedKeyValue,attr"`
+		} `xml:"encryptedKey"`
+	} `xml:"keyEncryptors>keyEncryptor"`
+}
+
+// decryptAgile decrypts a package encrypted with the agile encryption
+// (MS-OFFCRYPTO 2.3.4.10) given the XML descriptor of the encryption.
+func decryptAgile(descriptor []byte, encrypted []byte, password string) ([]byte, error) {
+	var encryption agileEncryption
+	if err := xml.Unmarshal(descriptor, &encryption); err != nil {
+		return nil, encryptedError(fmt.Sprintf("invalid encryption descriptor: %s", err.Error()))
+	}
+
+	keyData := encryption.KeyData
+	if keyData.CipherAlgorithm != "AES" || keyData.CipherChaining != "ChainingModeCBC" {
+		return nil, encryptedError(fmt.Sprintf("unsupported cipher %s", keyData.CipherAlgorithm))
+	}
+
+	if !isAesKeySize(keyData.KeyBits) || keyData.BlockSize != aes.BlockSize {
+		return nil, encryptedError(fmt.Sprintf("invalid key size %d or block size %d", keyData.KeyBits, keyData.BlockSize))
+	}
+
+	for _, encryptor := range encryption.KeyEncryptors {
+		key := encryptor.EncryptedKey
+		if key == nil {
+			continue
+		}
+
+		if key.CipherAlgorithm != "AES" || key.CipherChaining != "ChainingModeCBC" {
+			return nil, encryptedError(fmt.Sprintf("unsupported cipher %s", key.CipherAlgorithm))
+		}
+
+		if !isAesKeySize(key.KeyBits) {
+			return nil, encryptedError(fmt.Sprintf("invalid key size %d", key.KeyBits))
+		}
+
+		newHash, err := hashAlgorithm(key.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := decodeBase64(key.SaltValue, key.EncryptedVerifierHashInput, key.EncryptedVerifierHashValue,
+			key.EncryptedKeyValue)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.SpinCount < 0 || key.SpinCount > maxSpinCount {
+			return nil, encryptedError(fmt.Sprintf("invalid spin count %d", key.SpinCount))
+		}
+
+		var (
+			salt     = values[0]
+			keySize  = key.KeyBits / 8
+			baseHash = passwordHash(newHash, salt, password, key.SpinCount)
+		)
+
+		hashInput, err := decryptCbc(agileKey(newHash, baseHash, verifierHashInputBlockKey, keySize), salt, values[1])
+		if err != nil {
+			return nil, err
+		}
+
+		hashValue, err := decryptCbc(agileKey(newHash, baseHash, verifierHashValueBlockKey, keySize), salt, values[2])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(hashInput) < len(salt) {
+			return nil, encryptedError("invalid verifier")
+		}
+
+		h := newHash()
+		h.Write(hashInput[:len(salt)])
+
+		if len(hashValue) < h.Size() || !bytes.Equal(h.Sum(nil), hashValue[:h.Size()]) {
+			return nil, wrongPasswordError()
+		}
+
+		secretKey, err := decryptCbc(agileKey(newHash, baseHash, encryptedKeyValueBlockKey, keySize), salt, values[3])
+		if err != nil {
+			return nil, err
+		}
+
+		if keyData.KeyBits/8 > len(secretKey) {
+			return nil, encryptedError("invalid key size")
+		}
+
+		return decryptAgilePackage(encrypted, secretKey[:keyData.KeyBits/8], keyData.SaltValue, keyData.BlockSize,
+			keyData.HashAlgorithm)
+	}
+
+	return nil, encryptedError("the document is not encrypted with a password")
+}
+
+// decryptAgilePackage decrypts the EncryptedPackage stream segment by segment.
+// Each segment has its own initialization vector derived from the salt of the
+// key data and the index of the segment.
+func decryptAgilePackage(
+	encrypted []byte, key []byte, saltValue string, blockSize int, hashName string,
+) ([]byte, error) {
+	newHash, err := hashAlgorithm(hashName)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := decodeBase64(saltValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		size      = binary.LittleEndian.Uint64(encrypted)
+		data      = encrypted[8:]
+		decrypted = make([]byte, 0, len(data))
+	)
+
+	if size > uint64(len(data)) {
+		return nil, encryptedError("the encrypted package is truncated")
+	}
+
+	for i := 0; len(data) > 0; i++ {
+		segment := data
+		if len(segment) > agileSegmentSize {
+			segment = segment[:agileSegmentSize]
+		}
+		data = data[len(segment):]
+
+		var index [4]byte
+		binary.LittleEndian.PutUint32(index[:], uint32(i))
+
+		h := newHash()
+		h.Write(salt[0])
+		h.Write(index[:])
+
+		plain, err := decryptCbc(key, fitKey(h.Sum(nil), blockSize), segment)
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted = append(decrypted, plain...)
+	}
+
+	return decrypted[:size], nil
+}
+
+// decryptStandard decrypts a package encrypted with the standard encryption
+// (MS-OFFCRYPTO 2.3.4.5) given the rest of the EncryptionInfo stream after the
+// version.
+func decryptStandard(info []byte, encrypted []byte, password string) ([]byte, error) {
+	if len(info) < 8 {
+		return nil, encryptedError("truncated encryption header")
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(info[4:]))
+	if headerSize < 32 || 8+headerSize > len(info) {
+		return nil, encryptedError("truncated encryption header")
+	}
+
+	var (
+		header    = info[8 : 8+headerSize]
+		verifier  = info[8+headerSize:]
+		algorithm = binary.LittleEndian.Uint32(header[8:])
+		hashAlgId = binary.LittleEndian.Uint32(header[12:])
+		keySize   = int(binary.LittleEndian.Uint32(header[16:])) / 8
+	)
+
+	expectedKeySize, found := standardKeySizes[algorithm]
+	if !found {
+		return nil, encryptedError(fmt.Sprintf("unsupported algorithm 0x%x", algorithm))
+	}
+
+	if keySize != expectedKeySize {
+		return nil, encryptedError(fmt.Sprintf("invalid key size %d for algorithm 0x%x", keySize*8, algorithm))
+	}
+
+	if hashAlgId != algorithmSha1 && hashAlgId != 0 {
+		return nil, encryptedError(fmt.Sprintf("unsupported hash algorithm 0x%x", hashAlgId))
+	}
+
+	if len(verifier) < 4+16+16+4+32 || binary.LittleEndian.Uint32(verifier) != 16 {
+		return nil, encryptedError("invalid encryption verifier")
+	}
+
+	var (
+		salt                  = verifier[4:20]
+		encryptedVerifier     = verifier[20:36]
+		encryptedVerifierHash = verifier[40:72]
+		key                   = standardKey(salt, password, keySize)
+	)
+
+	plainVerifier, err := decryptEcb(key, encryptedVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	verifierHash, err := decryptEcb(key, encryptedVerifierHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if expected := sha1.Sum(plainVerifier); !bytes.Equal(expected[:], verifierHash[:sha1.Size]) {
+		return nil, wrongPasswordError()
+	}
+
+	size := binary.LittleEndian.Uint64(encrypted)
+	data := encrypted[8:]
+	data = data[:len(data)-len(data)%aes.BlockSize]
+
+	decrypted, err := decryptEcb(key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > uint64(len(decrypted)) {
+		return nil, encryptedError("the encrypted package is truncated")
+	}
+
+	return decrypted[:size], nil
+}
+
+// standardKey derives the key of the standard encryption from the password.
+func standardKey(salt []byte, password string, keySize int) []byte {
+	var (
+		baseHash = passwordHash(sha1.New, salt, password, standardSpinCount)
+		block    [4]byte
+	)
+
+	h := sha1.New()
+	h.Write(baseHash)
+	h.Write(block[:])
+	final := h.Sum(nil)
+
+	derive := func(constant byte) []byte {
+		buffer := bytes.Repeat([]byte{constant}, 64)
+		for i, b := range final {
+			buffer[i] ^= b
+		}
+
+		sum := sha1.Sum(buffer)
+		return sum[:]
+	}
+
+	return append(derive(0x36), derive(0x5c)...)[:keySize]
+}
+
+// passwordHash is the iterated hash of the salted password both encryption
+// methods start the key derivation with.
+func passwordHash(newHash func() hash.Hash, salt []byte, password string, spinCount int) []byte {
+	h := newHash()
+	h.Write(salt)
+	h.Write(utf16LittleEndian(password))
+	sum := h.Sum(nil)
+
+	var iterator [4]byte
+
+	for i := 0; i < spinCount; i++ {
+		binary.LittleEndian.PutUint32(iterator[:], uint32(i))
+
+		h.Reset()
+		h.Write(iterator[:])
+		h.Write(sum)
+		sum = h.Sum(sum[:0])
+	}
+
+	return sum
+}
+
+// agileKey derives the key of one of the blocks of the agile encryption.
+func agileKey(newHash func() hash.Hash, baseHash []byte, blockKey []byte, keySize int) []byte {
+	h := newHash()
+	h.Write(baseHash)
+	h.Write(blockKey)
+
+	return fitKey(h.Sum(nil), keySize)
+}
+
+// isAesKeySize tells whether the number of bits is the key size of one of the
+// AES variants.
+func isAesKeySize(bits int) bool {
+	return bits == 128 || bits == 192 || bits == 256
+}
+
+// fitKey truncates the hash to size bytes or pads it with 0x36 bytes.
+func fitKey(sum []byte, size int) []byte {
+	if len(sum) >= size {
+		return sum[:size]
+	}
+
+	return append(sum, bytes.Repeat([]byte{0x36}, size-len(sum))...)
+}
+
+// hashAlgorithm returns the hash function of the given name.
+func hashAlgorithm(name string) (func() hash.Hash, error) {
+	switch name {
+	case "SHA1", "SHA-1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA384":
+		return sha512.New384, nil
+	case "SHA512":
+		return sha512.New, nil
+	}
+
+	return nil, encryptedError(fmt.Sprintf("unsupported hash algorithm %s", name))
+}
+
+// decodeBase64 decodes the given base64 values.
+func decodeBase64(values ...string) ([][]byte, error) {
+	var decoded [][]byte
+
+	for _, value := range values {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, encryptedError(fmt.Sprintf("invalid base64 value: %s", err.Error()))
+		}
+
+		decoded = append(decoded, data)
+	}
+
+	return decoded, nil
+}
+
+// decryptCbc decrypts the data with AES in CBC mode.
+func decryptCbc(key []byte, iv []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, encryptedError(err.Error())
+	}
+
+	if len(data)%aes.BlockSize != 0 {
+		return nil, encryptedError("the encrypted data is not a multiple of the block size")
+	}
+
+	if len(iv) < aes.BlockSize {
+		return nil, encryptedError("the initialization vector is too short")
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv[:aes.BlockSize]).CryptBlocks(plain, data)
+
+	return plain, nil
+}
+
+// decryptEcb decrypts the data with AES in ECB mode.
+func decryptEcb(key []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, encryptedError(err.Error())
+	}
+
+	if len(data)%aes.BlockSize != 0 {
+		return nil, encryptedError("the encrypted data is not a multiple of the block size")
+	}
+
+	plain := make([]byte, len(data))
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Decrypt(plain[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+	}
+
+	return plain, nil
+}
+
+// utf16LittleEndian encodes the string in UTF-16LE.
+func utf16LittleEndian(s string) []byte {
+	var (
+		units   = utf16.Encode([]rune(s))
+		encoded = make([]byte, 2*len(units))
+	)
+
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(encoded[2*i:], u)
+	}
+
+	return encoded
+}
@@ -0,0 +1,200 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The kinds of errors that can be told apart with errors.Is.
+var (
+	// ErrNotZip is the kind of errors of inputs that are not zip archives.
+	ErrNotZip = errors.New("not a zip archive")
+	// ErrNotOoxml is the kind of errors of zip archives that are not OOXML
+	// packages.
+	ErrNotOoxml = errors.New("not an OOXML package")
+	// ErrUnsupported is the kind of errors of OOXML packages of a format the
+	// library doesn't handle.
+	ErrUnsupported = errors.New("unsupported format")
+	// ErrMissingPart is the kind of errors of packages that lack a part that
+	// is needed to process them.
+	ErrMissingPart = errors.New("missing part")
+	// ErrEncrypted is the kind of errors of password protected documents.
+	ErrEncrypted = errors.New("encrypted document")
+	// ErrWrongPassword is the kind of errors of encrypted documents that
+	// cannot be decrypted with the given password.
+	ErrWrongPassword = errors.New("wrong password")
+	// ErrMalformedXml is the kind of errors of parts that are not well-formed
+	// XML (see XmlError).
+	ErrMalformedXml = errors.New("malformed XML")
+)
+
+// Error is an error of one of the kinds above. Err is the underlying cause
+// (it can be nil).
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether the error is of the given kind.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// XmlError is the error of a part that is not well-formed XML. Part is the
+// name of the part, Offset is the byte offset within the part where the
+// problem was found and Err is the error of the XML parser. XmlErrors are of
+// the ErrMalformedXml kind.
+type XmlError struct {
+	Part   string
+	Offset int64
+	Err    error
+}
+
+func (e *XmlError) Error() string {
+	if e.Part == "" {
+		return fmt.Sprintf("Error while parsing xml file at offset %d: %s", e.Offset, e.Err.Error())
+	}
+
+	return fmt.Sprintf("Error while parsing xml file %s at offset %d: %s", e.Part, e.Offset, e.Err.Error())
+}
+
+// Is reports whether the target is ErrMalformedXml.
+func (e *XmlError) Is(target error) bool {
+	return target == ErrMalformedXml
+}
+
+// Unwrap returns the error of the XML parser.
+func (e *XmlError) Unwrap() error {
+	return e.Err
+}
+
+// HttpError is the error of a download that was answered with a status code
+// other than 2xx. Url is the URL of the document, StatusCode and Status are
+// the status code and the status line of the response.
+type HttpError struct {
+	Url        string
+	StatusCode int
+	Status     string
+}
+
+func (e *HttpError) Error() string {
+	return fmt.Sprintf("Failed to download %s: %s", e.Url, e.Status)
+}
+
+// oleSignature is the beginning of OLE compound files. Encrypted OOXML
+// documents (and the legacy binary Office formats) are stored in such files.
+var oleSignature = []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}
+
+// encryptedPackageName is the name of the stream holding the encrypted
+// package in an OLE compound file, in UTF-16.
+var encryptedPackageName = []byte("E\x00n\x00c\x00r\x00y\x00p\x00t\x00e\x00d\x00P\x00a\x00c\x00k\x00a\x00g\x00e\x00")
+
+// openError turns the error of opening an archive into an error of the
+// ErrNotZip or the ErrEncrypted kind if the data is not a zip archive.
+func openError(readerAt io.ReaderAt, size int64, err error) error {
+	if !errors.Is(err, zip.ErrFormat) {
+		return err
+	}
+
+	var header [8]byte
+	if n, _ := readerAt.ReadAt(header[:], 0); n == len(header) && bytes.Equal(header[:], oleSignature) {
+		if containsEncryptedPackage(io.NewSectionReader(readerAt, 0, size)) {
+			return &Error{Kind: ErrEncrypted, Message: "The document is encrypted", Err: err}
+		}
+
+		return &Error{
+			Kind:    ErrNotOoxml,
+			Message: "The document is a legacy binary Office document, not an OOXML package",
+			Err:     err,
+		}
+	}
+
+	return &Error{Kind: ErrNotZip, Message: fmt.Sprintf("Not a zip archive: %s", err.Error()), Err: err}
+}
+
+// openNonZip opens the documents that are not zip archives: Flat OPC
+// documents and, if there is a password, encrypted documents. err is the error
+// of opening the data as a zip archive, the error of openError is returned for
+// the other inputs.
+func openNonZip(readerAt io.ReaderAt, size int64, password string, err error) (ZipData, error) {
+	if errors.Is(err, zip.ErrFormat) && isXmlDocument(readerAt) {
+		flat, err := MakeFlatOpc(io.NewSectionReader(readerAt, 0, size))
+		if err != nil {
+			return nil, err
+		}
+
+		return flat, nil
+	}
+
+	err = openError(readerAt, size, err)
+	if password == "" || !errors.Is(err, ErrEncrypted) {
+		return nil, err
+	}
+
+	data, err := decryptPackage(readerAt, size, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return MakeZipFileFromBytes(data, "")
+}
+
+// openNonZipFile is openNonZip for documents given by their path.
+func openNonZipFile(path string, password string, err error) (ZipData, error) {
+	if !errors.Is(err, zip.ErrFormat) {
+		return nil, err
+	}
+
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, statErr := file.Stat()
+	if statErr != nil {
+		return nil, err
+	}
+
+	return openNonZip(file, info.Size(), password, err)
+}
+
+// containsEncryptedPackage tells whether the OLE compound file has an
+// EncryptedPackage stream by looking for its name.
+func containsEncryptedPackage(reader io.Reader) bool {
+	var (
+		buffer  = make([]byte, 64<<10)
+		overlap = len(encryptedPackageName) - 1
+		filled  int
+	)
+
+	for {
+		n, err := io.ReadFull(reader, buffer[filled:])
+		filled += n
+
+		if bytes.Contains(buffer[:filled], encryptedPackageName) {
+			return true
+		}
+
+		if err != nil {
+			return false
+		}
+
+		copy(buffer, buffer[filled-overlap:filled])
+		filled = overlap
+	}
+}
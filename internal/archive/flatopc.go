@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// flatOpcNamespace is the namespace of the elements of Flat OPC documents.
+const flatOpcNamespace = "http://schemas.microsoft.com/office/2006/xmlPackage"
+
+// contentTypesName is the name of the part listing the content types of the
+// parts of a package.
+const contentTypesName = "[Content_Types].xml"
+
+// FlatOpc is an implementation of the ZipData interface for Flat OPC documents:
+// the single XML file form of the packages (pkg:package) Word and PowerPoint
+// save as "XML Document" and "XML Presentation". The parts are held in memory.
+// As Flat OPC documents store the content types in the parts themselves, a
+// [Content_Types].xml part is synthesized from them.
+type FlatOpc struct {
+	files  []*zip.File
+	data   map[*zip.File][]byte
+	limits Limits
+	total  int64
+}
+
+// flatOpcPackage is the pkg:package root element.
+type flatOpcPackage struct {
+	XMLName xml.Name      `xml:"http://schemas.microsoft.com/office/2006/xmlPackage package"`
+	Parts   []flatOpcPart `xml:"http://schemas.microsoft.com/office/2006/xmlPackage part"`
+}
+
+// flatOpcPart is a pkg:part element: the content of the part is either XML or
+// base64 encoded binary data.
+type flatOpcPart struct {
+	Name        string `xml:"name,attr"`
+	ContentType string `xml:"contentType,attr"`
+	XmlData     *struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"http://schemas.microsoft.com/office/2006/xmlPackage xmlData"`
+	BinaryData *string `xml:"http://schemas.microsoft.com/office/2006/xmlPackage binaryData"`
+}
+
+// MakeFlatOpc creates a FlatOpc from a Flat OPC document read from the given
+// reader. Documents that are not well-formed XML are reported with an
+// XmlError, other XML documents with an error of the ErrNotOoxml kind.
+func MakeFlatOpc(reader io.Reader) (*FlatOpc, error) {
+	var (
+		decoder = xml.NewDecoder(reader)
+		pkg     flatOpcPackage
+	)
+
+	if err := decoder.Decode(&pkg); err != nil {
+		if _, ok := err.(xml.UnmarshalError); ok {
+			return nil, &Error{Kind: ErrNotOoxml, Message: "Not a Flat OPC document: " + err.Error(), Err: err}
+		}
+
+		return nil, &XmlError{Offset: decoder.InputOffset(), Err: err}
+	}
+
+	flat := &FlatOpc{data: map[*zip.File][]byte{}}
+	contentTypes := []string{}
+
+	for _, part := range pkg.Parts {
+		var (
+			name = strings.TrimPrefix(part.Name, "/")
+			data []byte
+			size int
+		)
+
+		switch {
+		case part.XmlData != nil:
+			data = bytes.TrimSpace(part.XmlData.Content)
+			size = len(data)
+		case part.BinaryData != nil:
+			encoded := strings.Join(strings.Fields(*part.BinaryData), "")
+
+			var err error
+			if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+				return nil, &Error{
+					Kind:    ErrNotOoxml,
+					Message: fmt.Sprintf("Invalid binary data in part %s: %s", name, err.Error()),
+					Err:     err,
+				}
+			}
+			size = len(encoded)
+		}
+
+		flat.add(name, data, size)
+		contentTypes = append(contentTypes, fmt.Sprintf(
+			`<Override PartName="/%s" ContentType="%s"/>`, escapeXml(name), escapeXml(part.ContentType),
+		))
+	}
+
+	if _, err := flat.FileByName(contentTypesName); err != nil {
+		flat.add(contentTypesName, []byte(
+			`<?xml version="1.0" encoding="UTF-8"?>`+
+				`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+				strings.Join(contentTypes, "")+`</Types>`,
+		), 0)
+	}
+
+	return flat, nil
+}
+
+// add adds a part to the package. The stored size is used as the compressed
+// size of the part (it is the size of the part without compression if it is
+// not positive).
+func (f *FlatOpc) add(name string, data []byte, storedSize int) {
+	if storedSize <= 0 {
+		storedSize = len(data)
+	}
+
+	file := &zip.File{FileHeader: zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		CompressedSize64:   uint64(storedSize),
+		UncompressedSize64: uint64(len(data)),
+	}}
+
+	f.files = append(f.files, file)
+	f.data[file] = data
+}
+
+// escapeXml escapes the text for an XML attribute value.
+func escapeXml(text string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
+
+// isXmlDocument tells whether the data starts like an XML document (an
+// optional byte order mark and white space followed by '<').
+func isXmlDocument(reader io.ReaderAt) bool {
+	var header [512]byte
+
+	n, _ := reader.ReadAt(header[:], 0)
+	start := bytes.TrimLeft(bytes.TrimPrefix(header[:n], []byte("\xef\xbb\xbf")), " \t\r\n")
+
+	return len(start) > 0 && start[0] == '<'
+}
+
+// Files returns the parts of the package.
+func (f *FlatOpc) Files() []*zip.File {
+	return f.files
+}
+
+// Close releases the parts of the package.
+func (f *FlatOpc) Close() error {
+	f.files, f.data = nil, nil
+	return nil
+}
+
+// Open opens a part of the package for reading. The reader reports a
+// LimitError if the part exceeds the limits of the package.
+func (f *FlatOpc) Open(file *zip.File) (io.ReadCloser, error) {
+	data, found := f.data[file]
+	if !found {
+		return nil, &Error{Kind: ErrMissingPart, Message: fmt.Sprintf("The file called %s not found", file.Name)}
+	}
+
+	if f.limits.MaxPartSize > 0 && file.UncompressedSize64 > uint64(f.limits.MaxPartSize) {
+		return nil, &LimitError{Limit: "MaxPartSize", Value: f.limits.MaxPartSize, Part: file.Name}
+	}
+
+	return &limitedPartReader{
+		reader: ioutil.NopCloser(bytes.NewReader(data)), file: file, limits: f.limits, total: &f.total,
+	}, nil
+}
+
+// SetLimits sets the limits of the package. The number of parts is checked
+// right away, the other limits are checked while reading the parts.
+func (f *FlatOpc) SetLimits(limits Limits) error {
+	f.limits = limits
+
+	if limits.MaxEntries > 0 && len(f.files) > limits.MaxEntries {
+		return &LimitError{Limit: "MaxEntries", Value: int64(limits.MaxEntries)}
+	}
+
+	return nil
+}
+
+// Limits returns the limits of the package.
+func (f *FlatOpc) Limits() Limits {
+	return f.limits
+}
+
+// FileByName finds the part with the given name or returns an error.
+func (f *FlatOpc) FileByName(name string) (*zip.File, error) {
+	return fileByName(f.files, name)
+}
@@ -0,0 +1,234 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// readAhead is the least number of bytes fetched by a range request.
+	readAhead = 256 << 10
+	// rangeCacheSize is the number of fetched bytes kept in memory.
+	rangeCacheSize = 8 << 20
+)
+
+// rangeSegment is a downloaded piece of a remote file.
+type rangeSegment struct {
+	offset int64
+	data   []byte
+}
+
+// httpRangeReader is an io.ReaderAt for a remote file that fetches the
+// requested pieces with HTTP Range requests. Every request fetches at least
+// readAhead bytes and the most recent pieces are cached, so the small reads
+// of archive/zip don't turn into separate requests.
+type httpRangeReader struct {
+	ctx        context.Context
+	url        string
+	options    UrlOptions
+	client     *http.Client
+	size       int64
+	downloaded int64
+	segments   []rangeSegment
+	mutex      sync.Mutex
+}
+
+var _ io.ReaderAt = (*httpRangeReader)(nil)
+
+// ReadAt reads len(p) bytes from the given offset of the remote file.
+func (r *httpRangeReader) ReadAt(p []byte, off int64) (n int, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if off < 0 {
+		return 0, errors.New(fmt.Sprintf("Negative offset: %d", off))
+	}
+
+	for n < len(p) {
+		position := off + int64(n)
+		if position >= r.size {
+			return n, io.EOF
+		}
+
+		segment, found := r.segmentAt(position)
+		if !found {
+			length := int64(len(p) - n)
+			if length < readAhead {
+				length = readAhead
+			}
+
+			if segment, err = r.fetch(position, length); err != nil {
+				return
+			}
+		}
+
+		n += copy(p[n:], segment.data[position-segment.offset:])
+	}
+
+	return
+}
+
+// segmentAt returns the cached segment containing the given offset.
+func (r *httpRangeReader) segmentAt(offset int64) (rangeSegment, bool) {
+	for _, s := range r.segments {
+		if offset >= s.offset && offset < s.offset+int64(len(s.data)) {
+			return s, true
+		}
+	}
+
+	return rangeSegment{}, false
+}
+
+// fetch downloads length bytes from the given offset (less at the end of the
+// file) and caches them.
+func (r *httpRangeReader) fetch(offset int64, length int64) (rangeSegment, error) {
+	if offset+length > r.size {
+		length = r.size - offset
+	}
+
+	resp, err := r.get(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	if err != nil {
+		return rangeSegment{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return rangeSegment{}, &HttpError{Url: r.url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return rangeSegment{}, errors.New(
+			fmt.Sprintf("Failed to download a part of %s: %s", r.url, resp.Status),
+		)
+	}
+
+	start, _, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return rangeSegment{}, err
+	}
+
+	if start != offset {
+		return rangeSegment{}, errors.New(
+			fmt.Sprintf("Unexpected range returned for %s: %s", r.url, resp.Header.Get("Content-Range")),
+		)
+	}
+
+	return r.store(offset, resp.Body, length)
+}
+
+// store reads a segment from the body of a range response and caches it. The
+// oldest segments are dropped when the cache grows over its size.
+func (r *httpRangeReader) store(offset int64, body io.Reader, length int64) (rangeSegment, error) {
+	if r.options.MaxSize > 0 && r.downloaded+length > r.options.MaxSize {
+		return rangeSegment{}, errors.New(
+			fmt.Sprintf("The download of %s exceeds %d bytes", r.url, r.options.MaxSize),
+		)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, length))
+	if err != nil {
+		return rangeSegment{}, err
+	}
+
+	if len(data) == 0 {
+		return rangeSegment{}, io.ErrUnexpectedEOF
+	}
+
+	r.downloaded += int64(len(data))
+
+	segment := rangeSegment{offset: offset, data: data}
+	r.segments = append(r.segments, segment)
+
+	cached := 0
+	for i := len(r.segments) - 1; i >= 0; i-- {
+		cached += len(r.segments[i].data)
+		if cached > rangeCacheSize && i < len(r.segments)-1 {
+			r.segments = r.segments[i+1:]
+			break
+		}
+	}
+
+	return segment, nil
+}
+
+// get sends a range request.
+func (r *httpRangeReader) get(byteRange string) (*http.Response, error) {
+	request, err := newUrlRequest(r.ctx, r.url, r.options)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Range", byteRange)
+
+	return r.client.Do(request)
+}
+
+// parseContentRange parses the value of a Content-Range header of the form:
+// "bytes start-end/size".
+func parseContentRange(value string) (start int64, end int64, size int64, err error) {
+	var (
+		spec          = strings.TrimPrefix(value, "bytes ")
+		rangeAndSize  = strings.SplitN(spec, "/", 2)
+		startAndEnd   []string
+		errorOfFormat = errors.New(fmt.Sprintf("Invalid Content-Range: \"%s\"", value))
+	)
+
+	if spec == value || len(rangeAndSize) != 2 {
+		err = errorOfFormat
+		return
+	}
+
+	if startAndEnd = strings.SplitN(rangeAndSize[0], "-", 2); len(startAndEnd) != 2 {
+		err = errorOfFormat
+		return
+	}
+
+	if start, err = strconv.ParseInt(startAndEnd[0], 10, 64); err != nil {
+		err = errorOfFormat
+		return
+	}
+
+	if end, err = strconv.ParseInt(startAndEnd[1], 10, 64); err != nil {
+		err = errorOfFormat
+		return
+	}
+
+	if size, err = strconv.ParseInt(rangeAndSize[1], 10, 64); err != nil {
+		err = errorOfFormat
+	}
+
+	return
+}
+
+// makeZipFileFromRangeResponse creates a ZipFile backed by range requests
+// from the response to the first range request (the one asking for the end of
+// the file where the central directory of the archive is).
+func makeZipFileFromRangeResponse(
+	ctx context.Context, url string, options UrlOptions, client *http.Client, resp *http.Response,
+) (ZipData, error) {
+	start, end, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &httpRangeReader{ctx: ctx, url: url, options: options, client: client, size: size}
+
+	if _, err = reader.store(start, resp.Body, end-start+1); err != nil {
+		return nil, err
+	}
+
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return openNonZip(reader, size, options.Password, err)
+	}
+
+	return &ZipFile{data: &zipReaderAtReader{reader: zipReader}}, nil
+}
@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+)
+
+// Limits restricts the resources processing an archive may use, which is
+// needed for archives from untrusted sources (e.g. zip bombs). Zero values
+// mean no limit.
+type Limits struct {
+	// MaxPartSize is the maximum decompressed size of a part in bytes.
+	MaxPartSize int64
+	// MaxTotalSize is the maximum number of decompressed bytes read from the
+	// archive.
+	MaxTotalSize int64
+	// MaxCompressionRatio is the maximum ratio of the decompressed and the
+	// compressed size of a part.
+	MaxCompressionRatio int64
+	// MaxEntries is the maximum number of files in the archive.
+	MaxEntries int
+	// MaxXmlDepth is the maximum nesting depth of the elements of an XML part.
+	MaxXmlDepth int
+	// MaxXmlTokens is the maximum number of tokens (elements, texts, etc.) of
+	// an XML part.
+	MaxXmlTokens int
+}
+
+// LimitError is the error reported when an archive exceeds one of its Limits.
+// Limit is the name of the exceeded field of Limits, Value is its value and
+// Part is the name of the part exceeding it (empty for MaxEntries and
+// MaxTotalSize).
+type LimitError struct {
+	Limit string
+	Value int64
+	Part  string
+}
+
+func (e *LimitError) Error() string {
+	if e.Part == "" {
+		return fmt.Sprintf("The document exceeds the %s limit (%d)", e.Limit, e.Value)
+	}
+
+	return fmt.Sprintf("The part %s exceeds the %s limit (%d)", e.Part, e.Limit, e.Value)
+}
+
+// limitedPartReader reads a part and reports a LimitError as soon as the
+// decompressed data exceeds the limits.
+type limitedPartReader struct {
+	reader io.ReadCloser
+	file   *zip.File
+	limits Limits
+	total  *int64
+	read   int64
+}
+
+func (r *limitedPartReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.read += int64(n)
+	*r.total += int64(n)
+
+	if r.limits.MaxPartSize > 0 && r.read > r.limits.MaxPartSize {
+		return n, &LimitError{Limit: "MaxPartSize", Value: r.limits.MaxPartSize, Part: r.file.Name}
+	}
+
+	if r.limits.MaxCompressionRatio > 0 &&
+		r.read > r.limits.MaxCompressionRatio*int64(r.file.CompressedSize64) &&
+		r.read > minimumRatioCheckSize {
+		return n, &LimitError{Limit: "MaxCompressionRatio", Value: r.limits.MaxCompressionRatio, Part: r.file.Name}
+	}
+
+	if r.limits.MaxTotalSize > 0 && *r.total > r.limits.MaxTotalSize {
+		return n, &LimitError{Limit: "MaxTotalSize", Value: r.limits.MaxTotalSize}
+	}
+
+	return n, err
+}
+
+func (r *limitedPartReader) Close() error {
+	return r.reader.Close()
+}
+
+// minimumRatioCheckSize is the size up to which the compression ratio of parts
+// is not checked: tiny parts (e.g. empty XML files full of namespace
+// declarations) can have high ratios without being harmful.
+const minimumRatioCheckSize = 64 << 10
+
+// SetLimits sets the limits of the archive. The number of entries is checked
+// right away, the other limits are checked while reading the parts.
+func (z *ZipFile) SetLimits(limits Limits) error {
+	z.limits = limits
+
+	if limits.MaxEntries > 0 && len(z.data.Files()) > limits.MaxEntries {
+		return &LimitError{Limit: "MaxEntries", Value: int64(limits.MaxEntries)}
+	}
+
+	return nil
+}
+
+// Limits returns the limits of the archive.
+func (z *ZipFile) Limits() Limits {
+	return z.limits
+}
+
+// Open opens a file of the archive for reading. The reader reports a
+// LimitError if the file exceeds the limits of the archive.
+func (z *ZipFile) Open(file *zip.File) (io.ReadCloser, error) {
+	if z.limits.MaxPartSize > 0 && file.UncompressedSize64 > uint64(z.limits.MaxPartSize) {
+		return nil, &LimitError{Limit: "MaxPartSize", Value: z.limits.MaxPartSize, Part: file.Name}
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &limitedPartReader{reader: reader, file: file, limits: z.limits, total: &z.total}, nil
+}
@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// Special sector numbers of OLE compound files.
+const (
+	oleEndOfChain       = 0xfffffffe
+	oleHeaderDifatCount = 109
+	oleDirectoryEntry   = 128
+	oleStreamObject     = 2
+	oleRootObject       = 5
+)
+
+// oleFile reads the streams of an OLE compound file (MS-CFB). Only reading
+// the streams by name is supported, which is all the encrypted OOXML
+// documents need.
+type oleFile struct {
+	reader          io.ReaderAt
+	size            int64
+	sectorSize      int64
+	miniSectorSize  int64
+	miniStreamLimit int64
+	fat             []uint32
+	miniFat         []uint32
+	entries         []oleEntry
+	miniStream      []byte
+}
+
+// oleEntry is a directory entry of a compound file.
+type oleEntry struct {
+	name        string
+	objectType  byte
+	startSector uint32
+	size        int64
+}
+
+// oleError is the error of compound files that cannot be read.
+func oleError(message string) error {
+	return errors.New(fmt.Sprintf("Invalid OLE compound file: %s", message))
+}
+
+// readOleFile reads the structure of the compound file.
+func readOleFile(reader io.ReaderAt, size int64) (*oleFile, error) {
+	header := make([]byte, 512)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+
+	if string(header[:8]) != string(oleSignature) {
+		return nil, oleError("wrong signature")
+	}
+
+	sectorShift := binary.LittleEndian.Uint16(header[30:])
+	miniSectorShift := binary.LittleEndian.Uint16(header[32:])
+	if sectorShift != 9 && sectorShift != 12 || miniSectorShift != 6 {
+		return nil, oleError("unsupported sector size")
+	}
+
+	f := &oleFile{
+		reader:          reader,
+		size:            size,
+		sectorSize:      1 << sectorShift,
+		miniSectorSize:  1 << miniSectorShift,
+		miniStreamLimit: int64(binary.LittleEndian.Uint32(header[56:])),
+	}
+
+	var (
+		fatSectorCount  = binary.LittleEndian.Uint32(header[44:])
+		firstDirSector  = binary.LittleEndian.Uint32(header[48:])
+		firstMiniFat    = binary.LittleEndian.Uint32(header[60:])
+		firstDifat      = binary.LittleEndian.Uint32(header[68:])
+		fatSectors      []uint32
+		sectorCount     = uint32(size / f.sectorSize)
+		entriesPerDifat = int(f.sectorSize/4) - 1
+	)
+
+	if fatSectorCount > sectorCount {
+		return nil, oleError("too many FAT sectors")
+	}
+
+	for i := 0; i < oleHeaderDifatCount && uint32(len(fatSectors)) < fatSectorCount; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(header[76+4*i:]))
+	}
+
+	for difat, n := firstDifat, uint32(0); uint32(len(fatSectors)) < fatSectorCount; n++ {
+		if difat >= sectorCount || n > sectorCount {
+			return nil, oleError("broken DIFAT chain")
+		}
+
+		sector, err := f.sector(difat)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < entriesPerDifat && uint32(len(fatSectors)) < fatSectorCount; i++ {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(sector[4*i:]))
+		}
+
+		difat = binary.LittleEndian.Uint32(sector[4*entriesPerDifat:])
+	}
+
+	for _, s := range fatSectors {
+		sector, err := f.sector(s)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < len(sector); i += 4 {
+			f.fat = append(f.fat, binary.LittleEndian.Uint32(sector[i:]))
+		}
+	}
+
+	directory, err := f.readChain(firstDirSector, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i+oleDirectoryEntry <= len(directory); i += oleDirectoryEntry {
+		entry := oleEntryFromBytes(directory[i : i+oleDirectoryEntry])
+		if f.sectorSize == 512 {
+			// Version 3 files only use the lower half of the size field.
+			entry.size &= 0xffffffff
+		}
+
+		if entry.size < 0 || entry.size > size {
+			return nil, oleError(fmt.Sprintf("invalid size of entry %s", entry.name))
+		}
+
+		f.entries = append(f.entries, entry)
+	}
+
+	if len(f.entries) == 0 || f.entries[0].objectType != oleRootObject {
+		return nil, oleError("missing root entry")
+	}
+
+	if firstMiniFat != oleEndOfChain {
+		miniFat, err := f.readChain(firstMiniFat, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i+4 <= len(miniFat); i += 4 {
+			f.miniFat = append(f.miniFat, binary.LittleEndian.Uint32(miniFat[i:]))
+		}
+
+		root := f.entries[0]
+		if f.miniStream, err = f.readChain(root.startSector, root.size); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// oleEntryFromBytes parses a directory entry.
+func oleEntryFromBytes(data []byte) oleEntry {
+	nameLength := int(binary.LittleEndian.Uint16(data[64:]))
+	if nameLength > 64 {
+		nameLength = 64
+	}
+
+	name := make([]uint16, 0, 32)
+	for i := 0; i+1 < nameLength; i += 2 {
+		if c := binary.LittleEndian.Uint16(data[i:]); c != 0 {
+			name = append(name, c)
+		}
+	}
+
+	return oleEntry{
+		name:        string(utf16.Decode(name)),
+		objectType:  data[66],
+		startSector: binary.LittleEndian.Uint32(data[116:]),
+		size:        int64(binary.LittleEndian.Uint64(data[120:])),
+	}
+}
+
+// sector reads a sector of the file.
+func (f *oleFile) sector(n uint32) ([]byte, error) {
+	offset := (int64(n) + 1) * f.sectorSize
+	if offset+f.sectorSize > f.size {
+		return nil, oleError(fmt.Sprintf("sector %d is out of the file", n))
+	}
+
+	data := make([]byte, f.sectorSize)
+	if _, err := f.reader.ReadAt(data, offset); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// readChain reads the sectors of a chain of the FAT. The result is truncated
+// to size unless it is negative.
+func (f *oleFile) readChain(start uint32, size int64) ([]byte, error) {
+	var data []byte
+
+	for n, s := 0, start; s != oleEndOfChain; n++ {
+		if int(s) >= len(f.fat) || n > len(f.fat) {
+			return nil, oleError("broken sector chain")
+		}
+
+		sector, err := f.sector(s)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, sector...)
+		s = f.fat[s]
+
+		if size >= 0 && int64(len(data)) >= size {
+			break
+		}
+	}
+
+	if size >= 0 {
+		if int64(len(data)) < size {
+			return nil, oleError("stream is shorter than its size")
+		}
+		data = data[:size]
+	}
+
+	return data, nil
+}
+
+// readMiniChain reads the mini sectors of a chain of the mini FAT.
+func (f *oleFile) readMiniChain(start uint32, size int64) ([]byte, error) {
+	var data []byte
+
+	for n, s := 0, start; int64(len(data)) < size; n++ {
+		offset := int64(s) * f.miniSectorSize
+		if s == oleEndOfChain || int(s) >= len(f.miniFat) || n > len(f.miniFat) ||
+			offset+f.miniSectorSize > int64(len(f.miniStream)) {
+			return nil, oleError("broken mini sector chain")
+		}
+
+		data = append(data, f.miniStream[offset:offset+f.miniSectorSize]...)
+		s = f.miniFat[s]
+	}
+
+	return data[:size], nil
+}
+
+// stream returns the contents of the stream with the given name. The second
+// return value is false if there is no such stream.
+func (f *oleFile) stream(name string) ([]byte, bool, error) {
+	for _, e := range f.entries {
+		if e.objectType != oleStreamObject || e.name != name {
+			continue
+		}
+
+		var (
+			data []byte
+			err  error
+		)
+
+		if e.size < f.miniStreamLimit {
+			data, err = f.readMiniChain(e.startSector, e.size)
+		} else {
+			data, err = f.readChain(e.startSector, e.size)
+		}
+
+		return data, true, err
+	}
+
+	return nil, false, nil
+}
@@ -9,13 +9,19 @@ package archive
 
 import (
 	"archive/zip"
+	"io"
 )
 
 // ZipData defines the common interface for different zip-handling types.
+// Close releases the resources (open files, temporary files) held by the
+// implementation, the files cannot be read after that. The files should be
+// read through Open which enforces the limits set by SetLimits.
 type ZipData interface {
 	Files() []*zip.File
-	close() error
+	Close() error
+	Open(file *zip.File) (io.ReadCloser, error)
+	SetLimits(limits Limits) error
+	Limits() Limits
 
 	FileByName(name string) (file *zip.File, err error)
-	FilesByName(substring string) (files []*zip.File, err error)
 }
@@ -9,10 +9,14 @@ package archive
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -35,55 +39,282 @@ func (r *zipFileReader) Close() error {
 	return r.reader.Close()
 }
 
-type zipUrlReader struct {
+type zipReaderAtReader struct {
 	reader *zip.Reader
+	closer io.Closer
 }
 
-var _ zipReader = (*zipUrlReader)(nil)
+var _ zipReader = (*zipReaderAtReader)(nil)
 
-func (r *zipUrlReader) Files() []*zip.File {
+func (r *zipReaderAtReader) Files() []*zip.File {
 	return r.reader.File
 }
 
-func (r *zipUrlReader) Close() error {
-	return nil
+func (r *zipReaderAtReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+
+	return r.closer.Close()
+}
+
+type zipTempFileReader struct {
+	reader *zip.Reader
+	file   *os.File
+}
+
+var _ zipReader = (*zipTempFileReader)(nil)
+
+func (r *zipTempFileReader) Files() []*zip.File {
+	return r.reader.File
+}
+
+// Close closes and removes the temporary file.
+func (r *zipTempFileReader) Close() error {
+	err := r.file.Close()
+
+	if removeErr := os.Remove(r.file.Name()); err == nil {
+		err = removeErr
+	}
+
+	return err
 }
 
 // ZipFile is an implementation of the ZipData interface for actual zip files.
 type ZipFile struct {
-	data zipReader
+	data   zipReader
+	limits Limits
+	total  int64
 }
 
 // MakeZipFile creates a ZipFile for an actual zip file given by its path.
-func MakeZipFile(path string) (*ZipFile, error) {
+// The password is used if the file is an encrypted document (it can be empty
+// otherwise). Flat OPC documents are accepted as well, they are returned as a
+// FlatOpc (so are they by the other constructors below).
+func MakeZipFile(path string, password string) (ZipData, error) {
 	reader, err := zip.OpenReader(path)
 
 	if err != nil {
-		return nil, err
+		return openNonZipFile(path, password, err)
 	}
 
 	return &ZipFile{data: &zipFileReader{reader}}, nil
 }
 
-// MakeZipFileFromUrl creates a ZipFile from a URL
-func MakeZipFileFromUrl(url string) (*ZipFile, error) {
-	resp, err := http.Get(url)
+// UrlOptions configures the download of the documents given by an URL.
+type UrlOptions struct {
+	// Client is used to send the request, http.DefaultClient is used if it
+	// is nil.
+	Client *http.Client
+	// Header contains the extra headers of the request (e.g. Authorization).
+	Header http.Header
+	// MaxSize is the maximum number of bytes that is downloaded, 0 means no
+	// limit.
+	MaxSize int64
+	// MemoryLimit is the size up to which the downloaded archive is kept in
+	// memory (see MakeZipFileFromStream), 0 means no limit.
+	MemoryLimit int64
+	// RangeRequests makes the archive download only the parts of the file
+	// that are read (using HTTP Range requests) if the server supports it.
+	// Otherwise the whole file is downloaded.
+	RangeRequests bool
+	// Password is used to decrypt the document if it is encrypted.
+	Password string
+}
+
+// MakeZipFileFromUrlContext creates a ZipFile from a URL. The request is bound
+// to the given context (with RangeRequests set, so are the later requests
+// fetching the parts of the file), and responses with a status code other than
+// 2xx are reported as errors.
+func MakeZipFileFromUrlContext(ctx context.Context, url string, options UrlOptions) (ZipData, error) {
+	request, err := newUrlRequest(ctx, url, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.RangeRequests {
+		// The end of the file contains the central directory of the archive,
+		// that's what is read first.
+		request.Header.Set("Range", fmt.Sprintf("bytes=-%d", readAhead))
+	}
+
+	client := options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &HttpError{Url: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	if resp.StatusCode == http.StatusPartialContent {
+		return makeZipFileFromRangeResponse(ctx, url, options, client, resp)
+	}
+
+	var body io.Reader = resp.Body
+
+	if options.MaxSize > 0 {
+		if resp.ContentLength > options.MaxSize {
+			return nil, errors.New(
+				fmt.Sprintf("The file at %s is larger than %d bytes", url, options.MaxSize),
+			)
+		}
+
+		body = &sizeLimitedReader{reader: resp.Body, remaining: options.MaxSize, url: url}
+	}
+
+	if options.MemoryLimit <= 0 {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+
+		return MakeZipFileFromBytes(data, options.Password)
+	}
+
+	return MakeZipFileFromStream(body, options.MemoryLimit, options.Password)
+}
+
+// newUrlRequest creates a GET request with the headers of the options.
+func newUrlRequest(ctx context.Context, url string, options UrlOptions) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	for name, values := range options.Header {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
+	return request, nil
+}
+
+// sizeLimitedReader reads at most remaining bytes from the reader and reports
+// an error if there would be more to read.
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+	url       string
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		var probe [1]byte
+
+		n, err := r.reader.Read(probe[:])
+		if n > 0 {
+			return 0, errors.New(fmt.Sprintf("The file at %s is larger than the size limit", r.url))
+		}
+
+		return 0, err
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+
+	return n, err
+}
+
+// MakeZipFileFromReaderAt creates a ZipFile from the first size bytes of
+// the given reader. The reader has to remain readable as long as the ZipFile
+// is in use. The password is used if the data is an encrypted document.
+func MakeZipFileFromReaderAt(readerAt io.ReaderAt, size int64, password string) (ZipData, error) {
+	reader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return openNonZip(readerAt, size, password, err)
+	}
+
+	return &ZipFile{data: &zipReaderAtReader{reader: reader}}, nil
+}
+
+// MakeZipFileFromBytes creates a ZipFile from a zip archive held in memory.
+func MakeZipFileFromBytes(data []byte, password string) (ZipData, error) {
+	return MakeZipFileFromReaderAt(bytes.NewReader(data), int64(len(data)), password)
+}
+
+// MakeZipFileFromFS creates a ZipFile from the file called name in the given
+// file system. Files that support random access (io.ReaderAt) are read in
+// place and kept open until the ZipFile is closed, other files are read into
+// memory.
+func MakeZipFileFromFS(fsys fs.FS, name string, password string) (ZipData, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		if info, err := file.Stat(); err == nil {
+			reader, err := zip.NewReader(readerAt, info.Size())
+			if err != nil {
+				defer file.Close()
+				return openNonZip(readerAt, info.Size(), password, err)
+			}
+
+			return &ZipFile{data: &zipReaderAtReader{reader: reader, closer: file}}, nil
+		}
+	}
+
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return MakeZipFileFromBytes(data, password)
+}
+
+// MakeZipFileFromStream creates a ZipFile from a reader that can only be read
+// sequentially (e.g. an HTTP request body or a pipe). Archives of at most
+// memoryLimit bytes are kept in memory, larger ones are spooled to a temporary
+// file that is removed when the ZipFile is closed.
+func MakeZipFileFromStream(stream io.Reader, memoryLimit int64, password string) (ZipData, error) {
+	var buffer bytes.Buffer
+
+	n, err := io.CopyN(&buffer, stream, memoryLimit+1)
+	if err == io.EOF {
+		return MakeZipFileFromBytes(buffer.Bytes(), password)
+	} else if err != nil {
+		return nil, err
+	}
+
+	file, err := ioutil.TempFile("", "ooxml2txt-*")
 	if err != nil {
 		return nil, err
 	}
 
-	return &ZipFile{data: &zipUrlReader{reader}}, nil
+	spooled := &zipTempFileReader{file: file}
+
+	if _, err = buffer.WriteTo(file); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+
+	rest, err := io.Copy(file, stream)
+	if err != nil {
+		spooled.Close()
+		return nil, err
+	}
+
+	spooled.reader, err = zip.NewReader(file, n+rest)
+	if err != nil {
+		defer spooled.Close()
+		return openNonZip(file, n+rest, password, err)
+	}
+
+	return &ZipFile{data: spooled}, nil
 }
 
 // Files returns the files inside the zip archive.
@@ -91,39 +322,31 @@ func (z *ZipFile) Files() []*zip.File {
 	return z.data.Files()
 }
 
-// close closes the zip reader.
-func (z *ZipFile) close() error {
+// Close releases the resources held by the zip file: the underlying file is
+// closed and temporary files are removed.
+func (z *ZipFile) Close() error {
 	return z.data.Close()
 }
 
 // FileByName finds the file with the given name or returns an error.
 func (z *ZipFile) FileByName(name string) (file *zip.File, err error) {
-	for _, f := range z.data.Files() {
+	return fileByName(z.data.Files(), name)
+}
+
+// fileByName finds the file with the given name among the files or returns an
+// error. As the part names of OOXML packages are case-insensitive, a file
+// whose name only differs in case is returned if there is no exact match.
+func fileByName(files []*zip.File, name string) (file *zip.File, err error) {
+	for _, f := range files {
 		if f.Name == name {
+			return f, nil
+		} else if file == nil && strings.EqualFold(f.Name, name) {
 			file = f
-			break
 		}
 	}
 
 	if file == nil {
-		err = errors.New(fmt.Sprintf("The file called %s not found", name))
-	}
-
-	return
-
-}
-
-// FilesByName finds all the files containing the given substring or
-// return an error.
-func (z *ZipFile) FilesByName(substring string) (files []*zip.File, err error) {
-	for _, f := range z.data.Files() {
-		if strings.Contains(f.Name, substring) {
-			files = append(files, f)
-		}
-	}
-
-	if len(files) == 0 {
-		err = errors.New(fmt.Sprintf("No file containing \"%s\" found", substring))
+		err = &Error{Kind: ErrMissingPart, Message: fmt.Sprintf("The file called %s not found", name)}
 	}
 
 	return
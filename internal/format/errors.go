@@ -0,0 +1,26 @@
+package format
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+)
+
+// XmlErrorAt turns an error of the decoder into an *archive.XmlError with the
+// offset the decoder has reached. The name of the part can be added with
+// InPart.
+func XmlErrorAt(decoder *xml.Decoder, err error) error {
+	return &archive.XmlError{Offset: decoder.InputOffset(), Err: err}
+}
+
+// InPart sets the name of the part in the *archive.XmlError the given error
+// contains (if it doesn't have one yet). Other errors are returned unchanged.
+func InPart(err error, part string) error {
+	var xmlErr *archive.XmlError
+
+	if errors.As(err, &xmlErr) && xmlErr.Part == "" {
+		xmlErr.Part = part
+	}
+
+	return err
+}
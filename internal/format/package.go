@@ -0,0 +1,212 @@
+package format
+
+import (
+	"encoding/xml"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// ContentTypesPath is the name of the part that lists the content types
+	// of every part in the package.
+	ContentTypesPath = "[Content_Types].xml"
+
+	// OfficeDocumentType is the relationship type that points from the
+	// package to its main part.
+	OfficeDocumentType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+
+	// RelationshipsNamespace is the namespace of the attributes (r:id,
+	// r:embed, etc.) that refer to relationships from within a part.
+	RelationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	// StrictRelationshipsNamespace is RelationshipsNamespace in Strict
+	// (ISO 29500 Strict) documents. The relationship types of Strict
+	// documents are in this namespace as well.
+	StrictRelationshipsNamespace = "http://purl.oclc.org/ooxml/officeDocument/relationships"
+)
+
+// strictRelationshipTypeNames maps the names of the Strict relationship types
+// that differ from their Transitional counterparts.
+var strictRelationshipTypeNames = map[string]string{
+	"extendedProperties": "extended-properties",
+	"customProperties":   "custom-properties",
+}
+
+// Relationship is a single entry of a relationships (.rels) part.
+type Relationship struct {
+	Id       string
+	Type     string
+	Target   string
+	External bool
+}
+
+// ContentTypes holds the contents of [Content_Types].xml: the default content
+// types keyed by file extension and the overrides keyed by part name (without
+// the leading slash). Both keys are stored in lower case as part names are
+// case-insensitive.
+type ContentTypes struct {
+	Defaults  map[string]string
+	Overrides map[string]string
+}
+
+// ContentTypeOf returns the content type of the given part. Overrides take
+// precedence over the defaults. An empty string is returned if the part has
+// no known content type.
+func (c *ContentTypes) ContentTypeOf(partName string) string {
+	partName = strings.ToLower(strings.TrimPrefix(partName, "/"))
+
+	if contentType, ok := c.Overrides[partName]; ok {
+		return contentType
+	}
+
+	extension := strings.ToLower(strings.TrimPrefix(path.Ext(partName), "."))
+	return c.Defaults[extension]
+}
+
+// ContentTypesFromXml parses the contents of [Content_Types].xml.
+func ContentTypesFromXml(contentTypesXml string) (contentTypes *ContentTypes, err error) {
+	var (
+		contents = strings.NewReader(contentTypesXml)
+		decoder  = xml.NewDecoder(contents)
+	)
+
+	contentTypes = &ContentTypes{
+		Defaults:  map[string]string{},
+		Overrides: map[string]string{},
+	}
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Default":
+				extension := strings.ToLower(AttrValue(t, "Extension"))
+				contentTypes.Defaults[extension] = AttrValue(t, "ContentType")
+			case "Override":
+				partName := strings.ToLower(strings.TrimPrefix(AttrValue(t, "PartName"), "/"))
+				contentTypes.Overrides[partName] = AttrValue(t, "ContentType")
+			}
+		default:
+		}
+	}
+
+	return
+}
+
+// ReadContentTypes reads and parses [Content_Types].xml from the package.
+func ReadContentTypes(zipReader archive.ZipData) (*ContentTypes, error) {
+	contentTypesXml, err := ReadXml(zipReader, ContentTypesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contentTypes, err := ContentTypesFromXml(contentTypesXml)
+	return contentTypes, InPart(err, ContentTypesPath)
+}
+
+// TransitionalRelationshipType returns the Transitional form of a relationship
+// type of a Strict document (e.g. the hyperlink relationship type in the
+// purl.oclc.org namespace). Other relationship types are returned unchanged.
+func TransitionalRelationshipType(relationshipType string) string {
+	name := strings.TrimPrefix(relationshipType, StrictRelationshipsNamespace+"/")
+	if name == relationshipType {
+		return relationshipType
+	}
+
+	if transitionalName, found := strictRelationshipTypeNames[name]; found {
+		name = transitionalName
+	}
+
+	return RelationshipsNamespace + "/" + name
+}
+
+// IsRelationshipsNamespace tells whether the namespace is the Transitional or
+// the Strict namespace of the attributes referring to relationships.
+func IsRelationshipsNamespace(namespace string) bool {
+	return namespace == RelationshipsNamespace || namespace == StrictRelationshipsNamespace
+}
+
+// RelationshipsFromXml parses the contents of a relationships (.rels) part.
+// The types of the relationships of Strict documents are turned into their
+// Transitional form (see TransitionalRelationshipType), so they can be
+// compared to the Transitional types.
+func RelationshipsFromXml(relsXml string) (relationships []Relationship, err error) {
+	var (
+		contents = strings.NewReader(relsXml)
+		decoder  = xml.NewDecoder(contents)
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Relationship" {
+				relationships = append(relationships, Relationship{
+					Id:       AttrValue(t, "Id"),
+					Type:     TransitionalRelationshipType(AttrValue(t, "Type")),
+					Target:   AttrValue(t, "Target"),
+					External: AttrValue(t, "TargetMode") == "External",
+				})
+			}
+		default:
+		}
+	}
+
+	return
+}
+
+// ResolveTarget returns the part name a relationship target points to.
+// Relative targets are resolved against the directory of the source part,
+// absolute ones against the package root. The result has no leading slash.
+func ResolveTarget(sourcePart, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+
+	dir := path.Dir(strings.TrimPrefix(sourcePart, "/"))
+	return strings.TrimPrefix(path.Join("/", dir, target), "/")
+}
+
+// AttrValue returns the value of the attribute of the element with the given
+// local name or an empty string if there is no such attribute.
+func AttrValue(element xml.StartElement, localName string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == localName {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// RelationshipIdAttr returns the value of the r:id attribute of the element
+// (i.e. the id attribute in the Transitional or Strict relationships
+// namespace) or an empty string if the element has no such attribute.
+func RelationshipIdAttr(element xml.StartElement) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == "id" && IsRelationshipsNamespace(a.Name.Space) {
+			return a.Value
+		}
+	}
+
+	return ""
+}
@@ -9,49 +9,12 @@ package format
 import (
 	"archive/zip"
 	"encoding/xml"
-	"errors"
-	"fmt"
 	"github.com/nagygr/ooxml2txt/internal/archive"
 	"io"
 	"io/ioutil"
 	"strings"
 )
 
-func ReadXmls(zipReader archive.ZipData, nameFragment string) (xmlTexts []string, err error) {
-	xmlFiles, err := zipReader.FilesByName(nameFragment)
-
-	if err != nil {
-		return []string{}, err
-	}
-
-	xmlTexts, err = ReadTextFromXmls(xmlFiles)
-	if err != nil {
-		return []string{}, err
-	}
-
-	return
-}
-
-func ReadTextFromXmls(xmlFiles []*zip.File) ([]string, error) {
-	xmlText := []string{}
-
-	for _, element := range xmlFiles {
-		documentReader, err := element.Open()
-		if err != nil {
-			return []string{}, err
-		}
-
-		text, err := XmlFileToString(documentReader)
-		if err != nil {
-			return []string{}, err
-		}
-
-		xmlText = append(xmlText, text)
-	}
-
-	return xmlText, nil
-}
-
 func ReadXml(zipReader archive.ZipData, path string) (text string, err error) {
 	var documentFile *zip.File
 	documentFile, err = zipReader.FileByName(path)
@@ -59,109 +22,73 @@ func ReadXml(zipReader archive.ZipData, path string) (text string, err error) {
 		return text, err
 	}
 
+	return readXmlFile(zipReader, documentFile)
+}
+
+// readXmlFile reads an XML part of the archive and checks it against the XML
+// limits of the archive.
+func readXmlFile(zipReader archive.ZipData, file *zip.File) (text string, err error) {
 	var documentReader io.ReadCloser
-	documentReader, err = documentFile.Open()
+	documentReader, err = zipReader.Open(file)
 	if err != nil {
 		return text, err
 	}
+	defer documentReader.Close()
 
 	text, err = XmlFileToString(documentReader)
+	if err != nil {
+		return text, err
+	}
+
+	err = CheckXmlLimits(text, file.Name, zipReader.Limits())
 	return
 }
 
-func XmlFileToString(reader io.Reader) (string, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return "", err
+// CheckXmlLimits checks the nesting depth and the number of tokens of an XML
+// part against the MaxXmlDepth and MaxXmlTokens limits. The part is only
+// parsed if any of these limits are set.
+func CheckXmlLimits(xmlText string, part string, limits archive.Limits) error {
+	if limits.MaxXmlDepth <= 0 && limits.MaxXmlTokens <= 0 {
+		return nil
 	}
-	return string(b), nil
-}
 
-func TextFromXml(xmlText string) (string, error) {
 	var (
-		contents = strings.NewReader(xmlText)
-		decoder  = xml.NewDecoder(contents)
-		text     strings.Builder
-		inText   bool = false
+		decoder = xml.NewDecoder(strings.NewReader(xmlText))
+		depth   int
+		tokens  int
 	)
 
 	for {
-		token, err := decoder.Token()
+		token, err := decoder.RawToken()
+		if err != nil {
+			// Either the end of the part or malformed XML, which is reported by
+			// the parsers of the part.
+			return nil
+		}
 
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return "",
-				errors.New(fmt.Sprintf("Error while parsing xml file: %s", err.Error()))
+		tokens++
+		if limits.MaxXmlTokens > 0 && tokens > limits.MaxXmlTokens {
+			return &archive.LimitError{Limit: "MaxXmlTokens", Value: int64(limits.MaxXmlTokens), Part: part}
 		}
 
-		switch t := token.(type) {
-		case xml.CharData:
-			if inText {
-				text.WriteString(string(t))
-			}
+		switch token.(type) {
 		case xml.StartElement:
-			if t.Name.Local == "t" {
-				inText = true
+			depth++
+			if limits.MaxXmlDepth > 0 && depth > limits.MaxXmlDepth {
+				return &archive.LimitError{Limit: "MaxXmlDepth", Value: int64(limits.MaxXmlDepth), Part: part}
 			}
 		case xml.EndElement:
-			if t.Name.Local == "t" {
-				inText = false
-			}
-		default:
+			depth--
 		}
 	}
-
-	return text.String(), nil
 }
 
-func LinksFromXml(xmlLinks string) (links []string, err error) {
-	const (
-		tagName    = "Relationship"
-		typeName   = "Type"
-		targetName = "Target"
-		urlType    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
-	)
-
-	var (
-		contents = strings.NewReader(xmlLinks)
-		decoder  = xml.NewDecoder(contents)
-		urlFound bool
-	)
-
-	for {
-		token, decErr := decoder.Token()
-		urlFound = false
-
-		if decErr == io.EOF {
-			break
-		} else if decErr != nil {
-			err = errors.New(fmt.Sprintf("Error while parsing xml file: %s", decErr.Error()))
-			return
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			if t.Name.Local == tagName {
-				var url string
-
-				for _, a := range t.Attr {
-					if a.Name.Local == typeName && a.Value == urlType {
-						urlFound = true
-					} else if a.Name.Local == targetName {
-						url = a.Value
-					}
-				}
-
-				if urlFound {
-					links = append(links, url)
-				}
-			}
-		default:
-		}
+func XmlFileToString(reader io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
 	}
-
-	return
+	return string(b), nil
 }
 
 func TextListFromXml(textXml string) (textList []string, err error) {
@@ -177,7 +104,8 @@ func TextListFromXml(textXml string) (textList []string, err error) {
 		if decErr == io.EOF {
 			break
 		} else if decErr != nil {
-			err = errors.New(fmt.Sprintf("Error while parsing xml file: %s", decErr.Error()))
+			err = XmlErrorAt(decoder, decErr)
+			return
 		}
 
 		switch t := token.(type) {
@@ -200,22 +128,6 @@ func TextListFromXml(textXml string) (textList []string, err error) {
 	return
 }
 
-func TextListFromXmls(textXmls []string) (textList []string, err error) {
-	for _, textXml := range textXmls {
-		tmpList, errXml := TextListFromXml(textXml)
-
-		if errXml != nil {
-			err = errXml
-			return
-		}
-
-		slideText := strings.Join(tmpList, " ")
-		textList = append(textList, slideText)
-	}
-
-	return
-}
-
 func XlsxSharedStringsFromXml(sharedStringsXml string) (sharedStrings []string, err error) {
 	var (
 		reader             = strings.NewReader(sharedStringsXml)
@@ -231,7 +143,8 @@ func XlsxSharedStringsFromXml(sharedStringsXml string) (sharedStrings []string,
 		if decErr == io.EOF {
 			break
 		} else if decErr != nil {
-			err = errors.New(fmt.Sprintf("Error while parsing xml file: %s", decErr.Error()))
+			err = XmlErrorAt(decoder, decErr)
+			return
 		}
 
 		switch t := token.(type) {
@@ -0,0 +1,261 @@
+// Package opc implements the model of OPC (Open Packaging Conventions)
+// packages published by pkg/opc: the parts of the package with their content
+// types (from [Content_Types].xml) and the relationships between them (from
+// the _rels/*.rels parts).
+package opc
+
+import (
+	"fmt"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"github.com/nagygr/ooxml2txt/internal/format"
+	"path"
+	"strings"
+)
+
+// Part is a part of a package. Name is the name of the part without the
+// leading slash (e.g. word/document.xml) and ContentType is its content type
+// as given by [Content_Types].xml.
+type Part struct {
+	Name        string
+	ContentType string
+}
+
+// Relationship is a relationship of a part (or of the package itself, in which
+// case Source is empty). Target is the target as written in the relationships
+// part: an URL for external relationships, a part name relative to the source
+// part otherwise (see TargetPart). The types of Strict documents are given in
+// their Transitional form.
+type Relationship struct {
+	Source   string
+	Id       string
+	Type     string
+	Target   string
+	External bool
+}
+
+// TargetPart returns the name of the part the relationship points to. It is
+// empty for external relationships.
+func (r Relationship) TargetPart() string {
+	if r.External {
+		return ""
+	}
+
+	return format.ResolveTarget(r.Source, r.Target)
+}
+
+// Package is the model of a package: its parts and the relationships between
+// them. The part names are case-insensitive.
+type Package struct {
+	parts         []*Part
+	partsByName   map[string]*Part
+	relationships map[string][]Relationship
+	errors        map[string]error
+}
+
+// Read reads the model of the package from the archive. Archives without
+// [Content_Types].xml are reported with an error of the archive.ErrNotOoxml
+// kind. The relationships parts that cannot be read don't make Read fail,
+// their errors are returned by Relationships.
+func Read(reader archive.ZipData) (*Package, error) {
+	if _, err := reader.FileByName(format.ContentTypesPath); err != nil {
+		return nil, &archive.Error{
+			Kind:    archive.ErrNotOoxml,
+			Message: fmt.Sprintf("Not an OOXML package: %s", err.Error()),
+			Err:     err,
+		}
+	}
+
+	contentTypes, err := format.ReadContentTypes(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		partsByName:   map[string]*Part{},
+		relationships: map[string][]Relationship{},
+		errors:        map[string]error{},
+	}
+
+	for _, file := range reader.Files() {
+		if file.Name == format.ContentTypesPath || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+
+		part := &Part{Name: file.Name, ContentType: contentTypes.ContentTypeOf(file.Name)}
+		pkg.parts = append(pkg.parts, part)
+		pkg.partsByName[strings.ToLower(file.Name)] = part
+
+		source, isRelationships := sourceOfRelationships(file.Name)
+		if !isRelationships {
+			continue
+		}
+
+		if err := pkg.readRelationships(reader, file.Name, source); err != nil {
+			pkg.errors[strings.ToLower(source)] = err
+		}
+	}
+
+	return pkg, nil
+}
+
+// readRelationships reads the relationships part with the given name that
+// belongs to the source part.
+func (p *Package) readRelationships(reader archive.ZipData, name string, source string) error {
+	relsXml, err := format.ReadXml(reader, name)
+	if err != nil {
+		return err
+	}
+
+	relationships, err := format.RelationshipsFromXml(relsXml)
+	if err != nil {
+		return format.InPart(err, name)
+	}
+
+	key := strings.ToLower(source)
+	for _, r := range relationships {
+		p.relationships[key] = append(p.relationships[key], Relationship{
+			Source:   source,
+			Id:       r.Id,
+			Type:     r.Type,
+			Target:   r.Target,
+			External: r.External,
+		})
+	}
+
+	return nil
+}
+
+// sourceOfRelationships returns the name of the part the relationships part
+// with the given name belongs to (empty for the relationships of the package).
+// The second return value is false if the name is not that of a relationships
+// part.
+func sourceOfRelationships(name string) (string, bool) {
+	dir, file := path.Split(name)
+
+	if path.Base(dir) != "_rels" || !strings.HasSuffix(file, ".rels") {
+		return "", false
+	}
+
+	source := path.Join(path.Dir(path.Clean(dir)), strings.TrimSuffix(file, ".rels"))
+	if source == "." {
+		source = ""
+	}
+
+	return source, true
+}
+
+// Parts returns the parts of the package in the order they are stored in it.
+// [Content_Types].xml is not a part.
+func (p *Package) Parts() []*Part {
+	return p.parts
+}
+
+// Part returns the part with the given name. The second return value is false
+// if there is no such part.
+func (p *Package) Part(name string) (*Part, bool) {
+	part, found := p.partsByName[strings.ToLower(strings.TrimPrefix(name, "/"))]
+	return part, found
+}
+
+// PartsByContentType returns the parts with the given content type in the
+// order they are stored in the package.
+func (p *Package) PartsByContentType(contentType string) (parts []*Part) {
+	for _, part := range p.parts {
+		if part.ContentType == contentType {
+			parts = append(parts, part)
+		}
+	}
+
+	return
+}
+
+// Relationships returns the relationships of the given part (use an empty
+// part name for the relationships of the package) in the order they are
+// listed. Parts without relationships yield an empty list, the error is only
+// set if the relationships part of the part cannot be read.
+func (p *Package) Relationships(part string) ([]Relationship, error) {
+	part = strings.ToLower(strings.TrimPrefix(part, "/"))
+
+	if err, found := p.errors[part]; found {
+		return []Relationship{}, err
+	}
+
+	if relationships, found := p.relationships[part]; found {
+		return relationships, nil
+	}
+
+	return []Relationship{}, nil
+}
+
+// RelationshipsOfType returns the relationships of the given part that have
+// the given type. Relationships parts that cannot be read are treated as
+// empty.
+func (p *Package) RelationshipsOfType(part string, relationshipType string) (matching []Relationship) {
+	relationships, _ := p.Relationships(part)
+
+	for _, r := range relationships {
+		if r.Type == relationshipType {
+			matching = append(matching, r)
+		}
+	}
+
+	return
+}
+
+// Relationship returns the relationship of the given part with the given id.
+// The second return value is false if there is no such relationship.
+func (p *Package) Relationship(part string, id string) (Relationship, bool) {
+	relationships, _ := p.Relationships(part)
+
+	for _, r := range relationships {
+		if r.Id == id {
+			return r, true
+		}
+	}
+
+	return Relationship{}, false
+}
+
+// TargetOf returns the target of the relationship of the given part with the
+// given id: the name of the part it points to or, for external relationships,
+// the URL. The second return value is false if there is no such relationship.
+func (p *Package) TargetOf(part string, id string) (string, bool) {
+	r, found := p.Relationship(part, id)
+	if !found {
+		return "", false
+	}
+
+	if r.External {
+		return r.Target, true
+	}
+
+	return r.TargetPart(), true
+}
+
+// MainPart returns the main part of the package, the one the officeDocument
+// relationship of the package points to. Packages without such a relationship
+// are reported with an error of the ErrNotOoxml kind, the ones whose main part
+// is missing with an error of the ErrMissingPart kind.
+func (p *Package) MainPart() (*Part, error) {
+	relationships, err := p.Relationships("")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range relationships {
+		if r.Type != format.OfficeDocumentType || r.External {
+			continue
+		}
+
+		if part, found := p.Part(r.TargetPart()); found {
+			return part, nil
+		}
+
+		return nil, &archive.Error{
+			Kind:    archive.ErrMissingPart,
+			Message: fmt.Sprintf("The main part %s not found", r.TargetPart()),
+		}
+	}
+
+	return nil, &archive.Error{Kind: archive.ErrNotOoxml, Message: "The package has no main part"}
+}
@@ -0,0 +1,227 @@
+package format
+
+import (
+	"context"
+	"fmt"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"io/fs"
+)
+
+// Kind tells which of the supported formats a document is.
+type Kind int
+
+const (
+	// KindUnknown is the kind of packages that are not recognized.
+	KindUnknown Kind = iota
+	// KindDocx is the kind of text documents (Docx).
+	KindDocx
+	// KindPptx is the kind of presentations (Pptx).
+	KindPptx
+	// KindXlsx is the kind of spreadsheet documents (Xlsx).
+	KindXlsx
+)
+
+// String returns the usual file extension of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindDocx:
+		return "docx"
+	case KindPptx:
+		return "pptx"
+	case KindXlsx:
+		return "xlsx"
+	default:
+		return "unknown"
+	}
+}
+
+// Document is the common interface of Docx, Pptx and Xlsx. It is returned by
+// Open, OpenUrl, OpenReader, OpenBytes, OpenFS and OpenStream; a type switch
+// can be used to get to the concrete type:
+//
+//	switch d := doc.(type) {
+//	case *Docx:
+//		fmt.Println(d.Text)
+//	case *Pptx:
+//		fmt.Println(d.Text)
+//	case *Xlsx:
+//		fmt.Println(d.Text)
+//	}
+type Document interface {
+	// Kind returns the format of the document.
+	Kind() Kind
+	// Variant returns the variant of the format (e.g. VariantDocm for a
+	// macro-enabled text document).
+	Variant() Variant
+	// HasVbaProject tells whether the document carries a VBA project (i.e.
+	// macros).
+	HasVbaProject() bool
+}
+
+var (
+	_ Document = (*Docx)(nil)
+	_ Document = (*Pptx)(nil)
+	_ Document = (*Xlsx)(nil)
+)
+
+// kindOfContentType maps the content type of the main part of a package to the
+// kind of the document.
+func kindOfContentType(contentType string) Kind {
+	return variantOfContentType(contentType).Kind()
+}
+
+// Open opens the document given by its path and returns it as a Docx, Pptx or
+// Xlsx depending on its content. The format is detected from the package's
+// [Content_Types].xml and _rels/.rels, the extension of the file is ignored.
+func Open(path string, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFile(path, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+// OpenUrl opens the document given by an URL. See Open for the details of the
+// format detection.
+func OpenUrl(url string, opts ...Option) (Document, error) {
+	return OpenUrlContext(context.Background(), url, opts...)
+}
+
+// OpenUrlContext opens the document given by an URL, the download is bound to
+// the given context. See MakeDocxFromUrlContext for the options of the download
+// and Open for the details of the format detection.
+func OpenUrlContext(ctx context.Context, url string, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromUrlContext(ctx, url, settings.urlOptions())
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+// OpenReader opens the document held by the given reader (size is the length
+// of the document in bytes). See Open for the details of the format detection.
+func OpenReader(readerAt io.ReaderAt, size int64, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromReaderAt(readerAt, size, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+// OpenBytes opens a document held in memory. See Open for the details of the
+// format detection.
+func OpenBytes(data []byte, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromBytes(data, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+// OpenFS opens the document called name in the given file system. See Open for
+// the details of the format detection.
+func OpenFS(fsys fs.FS, name string, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromFS(fsys, name, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+// OpenStream opens a document read from the given reader. The document is
+// buffered in memory or in a temporary file depending on its size (see
+// WithSpoolThreshold). See Open for the details of the format detection.
+func OpenStream(stream io.Reader, opts ...Option) (Document, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromStream(stream, settings.spoolThreshold, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return openFromReader(reader, settings)
+}
+
+func openFromReader(reader archive.ZipData, settings options) (Document, error) {
+	pkg, err := readPackage(reader, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	mainPart, err := pkg.MainPart()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kindOfContentType(mainPart.ContentType) {
+	case KindDocx:
+		doc, err := makeDocxFromReader(reader, pkg, settings)
+		if err != nil {
+			return nil, err
+		}
+		return doc, nil
+	case KindPptx:
+		ppt, err := makePptxFromReader(reader, pkg, settings)
+		if err != nil {
+			return nil, err
+		}
+		return ppt, nil
+	case KindXlsx:
+		xls, err := makeXlsxFromReader(reader, pkg, settings)
+		if err != nil {
+			return nil, err
+		}
+		return xls, nil
+	default:
+		return nil, &archive.Error{
+			Kind: ErrUnsupported,
+			Message: fmt.Sprintf(
+				"The main part %s has an unsupported content type: \"%s\"", mainPart.Name, mainPart.ContentType,
+			),
+		}
+	}
+}
+
+// readPackage reads the model of the package after applying the limits of the
+// settings to the archive. Archives without [Content_Types].xml are not OOXML
+// packages, they are reported with an error of the ErrNotOoxml kind.
+func readPackage(reader archive.ZipData, settings options) (*opc.Package, error) {
+	if err := reader.SetLimits(settings.limits); err != nil {
+		return nil, err
+	}
+
+	return opc.Read(reader)
+}
+
+// mainPartOrDefault returns the name of the main part of the package or the
+// given default name if the package doesn't declare its main part.
+func mainPartOrDefault(pkg *opc.Package, defaultName string) string {
+	if mainPart, err := pkg.MainPart(); err == nil {
+		return mainPart.Name
+	}
+
+	return defaultName
+}
@@ -0,0 +1,335 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOpeningKnownFormats(t *testing.T) {
+	expected := map[string]Kind{
+		"../../test_data/example.docx": KindDocx,
+		"../../test_data/example.pptx": KindPptx,
+		"../../test_data/example.xlsx": KindXlsx,
+	}
+
+	for path, kind := range expected {
+		doc, err := Open(path)
+
+		if err != nil {
+			t.Errorf("Expected to open %s successfully: %s", path, err.Error())
+			continue
+		}
+
+		if doc.Kind() != kind {
+			t.Errorf("Expected %s to be a %s, was: %s", path, kind, doc.Kind())
+		}
+	}
+}
+
+func TestOpeningMisnamedDocument(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	path := filepath.Join(t.TempDir(), "example.xlsx")
+	if err = ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %s", path, err.Error())
+	}
+
+	doc, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	switch d := doc.(type) {
+	case *Docx:
+		text := "The largest city"
+		if !strings.HasPrefix(d.Text, text) {
+			t.Errorf("Expected the text to start with: \"%s\"", text)
+		}
+	default:
+		t.Errorf("Expected a *Docx, got: %T", doc)
+	}
+}
+
+func TestOpeningFromMemory(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.pptx")
+	if err != nil {
+		t.Fatalf("Failed to read the example presentation: %s", err.Error())
+	}
+
+	doc, err := OpenBytes(content)
+	if err != nil || doc.Kind() != KindPptx {
+		t.Errorf("Expected to open the presentation from bytes: %v", err)
+	}
+
+	doc, err = OpenReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil || doc.Kind() != KindPptx {
+		t.Errorf("Expected to open the presentation from a reader: %v", err)
+	}
+
+	if _, err = OpenBytes(content[:len(content)/2]); err == nil {
+		t.Errorf("Expected an error for a truncated document")
+	}
+}
+
+func TestOpeningFromStream(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	for _, threshold := range []int64{int64(len(content)), 1024} {
+		doc, err := OpenStream(bytes.NewBuffer(content), WithSpoolThreshold(threshold))
+		if err != nil || doc.Kind() != KindDocx {
+			t.Errorf("Expected to open the document from a stream (threshold: %d): %v", threshold, err)
+		}
+	}
+
+	if entries, _ := ioutil.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected the spooled documents to be removed, found: %d files", len(entries))
+	}
+
+	if _, err = OpenStream(bytes.NewBuffer(content[:len(content)/2]), WithSpoolThreshold(1024)); err == nil {
+		t.Errorf("Expected an error for a truncated document")
+	}
+
+	if entries, _ := ioutil.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected the spooled document to be removed after an error")
+	}
+}
+
+func TestOpeningFromFS(t *testing.T) {
+	fsys := os.DirFS("../../test_data")
+
+	doc, err := OpenFS(fsys, "example.xlsx")
+	if err != nil || doc.Kind() != KindXlsx {
+		t.Errorf("Expected to open the spreadsheet from the file system: %v", err)
+	}
+
+	if _, err = OpenFS(fsys, "wrong_example.xlsx"); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestOpeningUrlContext(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/slow":
+			<-r.Context().Done()
+		case r.Header.Get("Authorization") != "Bearer token":
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		default:
+			w.Write(content)
+		}
+	}))
+	defer server.Close()
+
+	doc, err := OpenUrlContext(
+		context.Background(), server.URL+"/example.docx",
+		WithHttpClient(server.Client()), WithHttpHeader("Authorization", "Bearer token"),
+	)
+	if err != nil || doc.Kind() != KindDocx {
+		t.Errorf("Expected to download the document: %v", err)
+	}
+
+	_, err = MakeDocxFromUrlContext(context.Background(), server.URL+"/example.docx")
+
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an *HttpError telling the status code, got: %v", err)
+	} else if httpErr.Url != server.URL+"/example.docx" || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected the error to tell the URL and the status: %v", err)
+	}
+
+	_, err = MakeDocxFromUrlContext(
+		context.Background(), server.URL+"/example.docx",
+		WithHttpHeader("Authorization", "Bearer token"), WithMaxDownloadSize(1024),
+	)
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected an error for a document over the size limit, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err = OpenUrlContext(ctx, server.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the download to be cancelled, got: %v", err)
+	}
+}
+
+func TestOpeningUrlWithRangeRequests(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	// The example document extended with a large media file that is never
+	// read.
+	source, _ := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	var large bytes.Buffer
+	writer := zip.NewWriter(&large)
+
+	for _, f := range source.File {
+		w, _ := writer.Create(f.Name)
+		r, _ := f.Open()
+		io.Copy(w, r)
+		r.Close()
+	}
+
+	media, _ := writer.CreateHeader(&zip.FileHeader{Name: "word/media/video.bin", Method: zip.Store})
+	media.Write(make([]byte, 4<<20))
+	writer.Close()
+
+	var served int64
+	ranges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter := &countingWriter{ResponseWriter: w}
+		http.ServeContent(counter, r, "large.docx", time.Time{}, bytes.NewReader(large.Bytes()))
+		served += counter.count
+	}))
+	defer ranges.Close()
+
+	noRanges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(large.Bytes())
+	}))
+	defer noRanges.Close()
+
+	expected, _ := MakeDocx("../../test_data/example.docx")
+
+	for _, url := range []string{ranges.URL, noRanges.URL} {
+		doc, err := MakeDocxFromUrlContext(context.Background(), url, WithRangeRequests())
+		if err != nil {
+			t.Errorf("Expected to download the document from %s: %s", url, err.Error())
+			continue
+		}
+
+		if doc.Text != expected.Text {
+			t.Errorf("Expected the same text as the one of the example document")
+		}
+	}
+
+	if served == 0 || served > 1<<20 {
+		t.Errorf("Expected only the needed parts to be downloaded, downloaded: %d bytes", served)
+	}
+}
+
+// countingWriter counts the bytes of the response body.
+type countingWriter struct {
+	http.ResponseWriter
+	count int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+func TestReleasingFiles(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	missing, err := ioutil.ReadFile("../../test_data/broken_missing_document_xml.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the broken document: %s", err.Error())
+	}
+
+	fsys := &trackingFS{MapFS: fstest.MapFS{
+		"example.docx": &fstest.MapFile{Data: content},
+		"broken.docx":  &fstest.MapFile{Data: content[:len(content)/2]},
+		"missing.docx": &fstest.MapFile{Data: missing},
+	}}
+
+	if _, err = OpenFS(fsys, "example.docx"); err != nil {
+		t.Errorf("Expected to open the document: %s", err.Error())
+	}
+
+	if _, err = MakeDocxFromFS(fsys, "broken.docx"); err == nil {
+		t.Errorf("Expected an error for a truncated document")
+	}
+
+	if _, err = MakeDocxFromFS(fsys, "missing.docx"); err == nil {
+		t.Errorf("Expected an error for a document without its main part")
+	}
+
+	if fsys.opened != 3 || fsys.closed != fsys.opened {
+		t.Errorf("Expected every opened file to be closed, opened: %d, closed: %d", fsys.opened, fsys.closed)
+	}
+}
+
+// trackingFS counts the files opened and closed.
+type trackingFS struct {
+	fstest.MapFS
+	opened int
+	closed int
+}
+
+func (f *trackingFS) Open(name string) (fs.File, error) {
+	file, err := f.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.opened++
+	return &trackingFile{File: file, fsys: f}, nil
+}
+
+type trackingFile struct {
+	fs.File
+	fsys *trackingFS
+}
+
+func (f *trackingFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.File.(io.ReaderAt).ReadAt(p, off)
+}
+
+func (f *trackingFile) Close() error {
+	f.fsys.closed++
+	return f.File.Close()
+}
+
+func TestOpeningBadPath(t *testing.T) {
+	path := "../../test_data/wrong_example.docx"
+	_, err := Open(path)
+
+	if err == nil {
+		t.Errorf("Expected to fail to open %s successfully", path)
+	}
+}
+
+func TestOpeningBrokenDocument(t *testing.T) {
+	path := "../../test_data/broken_missing_document_xml.docx"
+	doc, err := Open(path)
+
+	if err == nil {
+		t.Errorf("Expected to get an error due to the missing document.xml.")
+	}
+
+	if doc != nil {
+		t.Errorf("Expected no document to be returned on error, got: %T", doc)
+	}
+}
@@ -10,112 +10,246 @@ package format
  */
 
 import (
+	"context"
 	"github.com/nagygr/ooxml2txt/internal/archive"
 	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"io/fs"
+	"strings"
 )
 
+const docxMainPart = "word/document.xml"
+
 // Docx handles docx documents. Its fields contain the textual information
 // corresponding to the different elements of the document: Text contains the
-// document text, Links is a list of links that appear in the document (the
-// text part contains references to the links), Footnotes contains the list of
-// footnotes, and Headers and Footers are also lists and contain the headers
-// and footers of the document.
+// document text with the paragraphs separated by newlines, Paragraphs contains
+// the same paragraphs as a list, Tables contains the structure of the tables
+// of the document (their text is also part of Text and Paragraphs), Links is
+// the list of hyperlinks of the document in the order they appear in the text
+// (the WithInlineLinks option puts their targets into the text as well),
+// Footnotes and Endnotes contain the list of footnotes and endnotes (with the
+// ids their reference marks use), and Headers and Footers are also lists and
+// contain the headers and footers of the document. Sections tells which of
+// the headers and footers belong to which section of the document. Comments
+// lists the reviewers' comments together with the text they refer to.
+// Revisions lists the tracked changes of the document, the way they are
+// reflected in the text can be set with the WithRevisions option.
+// Properties contains the metadata of the document (title, author, etc.).
+// Macro-enabled documents and templates (docm, dotx, dotm) are handled the same
+// way, their Variant method tells which one the document is.
 type Docx struct {
-	zipReader archive.ZipData
-	Text      string
-	Links     []string
-	Footnotes []string
-	Headers   []string
-	Footers   []string
+	Text       string
+	Paragraphs []string
+	Tables     []Table
+	Links      []Link
+	Footnotes  []Note
+	Endnotes   []Note
+	Headers    []string
+	Footers    []string
+	Sections   []Section
+	Comments   []Comment
+	Revisions  []Revision
+	Properties Properties
+
+	variant    Variant
+	vbaProject bool
 }
 
 // MakeDocx creates a Docx that parses the document given by its path. The
 // returned instance contains the valid contents of the document if there was
 // no error while processing it. If there was an error, it is reported in the
 // returned error value).
-func MakeDocx(path string) (*Docx, error) {
-	reader, err := archive.MakeZipFile(path)
+func MakeDocx(path string, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFile(path, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makeDocxFromReader(reader)
+	return makeDocxFromReader(reader, nil, settings)
 }
 
 // MakeDocxFromUrl creates a Docx that parses the document given by an URL. The
 // returned instance contains the valid contents of the document if there was
 // no error while processing it. If there was an error, it is reported in the
 // returned error value).
-func MakeDocxFromUrl(url string) (*Docx, error) {
-	reader, err := archive.MakeZipFileFromUrl(url)
+func MakeDocxFromUrl(url string, opts ...Option) (*Docx, error) {
+	return MakeDocxFromUrlContext(context.Background(), url, opts...)
+}
+
+// MakeDocxFromUrlContext creates a Docx from an URL to a document. The download
+// is bound to the given context and can be configured with the WithHttpClient,
+// WithHttpHeader and WithMaxDownloadSize options. Responses with a status code
+// other than 2xx are reported as errors.
+func MakeDocxFromUrlContext(ctx context.Context, url string, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromUrlContext(ctx, url, settings.urlOptions())
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makeDocxFromReader(reader)
+	return makeDocxFromReader(reader, nil, settings)
 }
 
-func makeDocxFromReader(reader archive.ZipData) (*Docx, error) {
-	textXml, err := ReadXml(reader, "word/document.xml")
+// MakeDocxFromReader creates a Docx from a document held by the given
+// reader (size is the length of the document in bytes). The reader is only
+// used during the call, the document is fully read by the time it returns.
+func MakeDocxFromReader(readerAt io.ReaderAt, size int64, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromReaderAt(readerAt, size, settings.password)
+
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
+
+	return makeDocxFromReader(reader, nil, settings)
+}
+
+// MakeDocxFromBytes creates a Docx from a document held in memory.
+func MakeDocxFromBytes(data []byte, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromBytes(data, settings.password)
 
-	text, err := TextFromXml(textXml)
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
+
+	return makeDocxFromReader(reader, nil, settings)
+}
+
+// MakeDocxFromFS creates a Docx from the document called name in the given file
+// system (e.g. an embed.FS or the result of os.DirFS).
+func MakeDocxFromFS(fsys fs.FS, name string, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromFS(fsys, name, settings.password)
 
-	linksXml, err := ReadXml(reader, "word/_rels/document.xml.rels")
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
+
+	return makeDocxFromReader(reader, nil, settings)
+}
+
+// MakeDocxFromStream creates a Docx from a document read from the given reader
+// (e.g. an HTTP request body). Only documents smaller than the spool threshold
+// (see WithSpoolThreshold) are held in memory, larger ones are buffered in a
+// temporary file.
+func MakeDocxFromStream(stream io.Reader, opts ...Option) (*Docx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromStream(stream, settings.spoolThreshold, settings.password)
 
-	links, err := LinksFromXml(linksXml)
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	headersXmls, err := ReadXmls(reader, "header")
-	var headers []string
+	return makeDocxFromReader(reader, nil, settings)
+}
 
-	if err == nil {
-		headers, err = TextListFromXmls(headersXmls)
+// Kind returns KindDocx.
+func (d *Docx) Kind() Kind {
+	return KindDocx
+}
+
+// Variant returns the variant of the document (VariantDocx, VariantDocm,
+// VariantDotx or VariantDotm).
+func (d *Docx) Variant() Variant {
+	return d.variant
+}
+
+// HasVbaProject tells whether the document carries a VBA project.
+func (d *Docx) HasVbaProject() bool {
+	return d.vbaProject
+}
+
+// makeDocxFromReader processes the archive with the given settings. If pkg is
+// nil, the model of the package is read from the archive. The main part is
+// looked up in the package (word/document.xml is used for packages that don't
+// declare it).
+func makeDocxFromReader(reader archive.ZipData, pkg *opc.Package, settings options) (*Docx, error) {
+	if pkg == nil {
+		var err error
+		if pkg, err = readPackage(reader, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	mainPart := mainPartOrDefault(pkg, docxMainPart)
+
+	textXml, err := ReadXml(reader, mainPart)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := pkg.Relationships(mainPart); err != nil {
+		return nil, err
 	}
 
+	body, err := parseDocxBody(textXml, pkg, mainPart, settings)
 	if err != nil {
-		headers = []string{}
+		return nil, InPart(err, mainPart)
 	}
 
-	footersXmls, err := ReadXmls(reader, "footer")
-	var footers []string
+	comments, err := readComments(reader, pkg, mainPart, body.commentAnchors)
 
-	if err == nil {
-		footers, err = TextListFromXmls(footersXmls)
+	if isLimitError(err) {
+		return nil, err
+	} else if err != nil {
+		comments = []Comment{}
 	}
 
+	sections, headers, footers, err := readSections(reader, pkg, mainPart, body, settings)
+
 	if err != nil {
-		footers = []string{}
+		return nil, err
 	}
 
-	footnotesXml, err := ReadXml(reader, "word/footnotes.xml")
-	var footnotes []string
+	footnotes, err := readNotes(
+		reader, pkg, mainPart, footnotesRelationshipType, "footnote", settings.revisions,
+	)
 
-	if err == nil {
-		footnotes, err = TextListFromXml(footnotesXml)
+	if isLimitError(err) {
+		return nil, err
+	} else if err != nil {
+		footnotes = []Note{}
 	}
 
+	endnotes, err := readNotes(
+		reader, pkg, mainPart, endnotesRelationshipType, "endnote", settings.revisions,
+	)
+
+	if isLimitError(err) {
+		return nil, err
+	} else if err != nil {
+		endnotes = []Note{}
+	}
+
+	properties, err := readProperties(reader, pkg)
 	if err != nil {
-		footnotes = []string{}
+		return nil, err
 	}
 
 	return &Docx{
-		zipReader: reader,
-		Text:      text,
-		Links:     links,
-		Footnotes: footnotes,
-		Headers:   headers,
-		Footers:   footers}, nil
+		Text:       strings.Join(body.paragraphs, "\n"),
+		Paragraphs: body.paragraphs,
+		Tables:     body.tables,
+		Links:      body.links,
+		Footnotes:  footnotes,
+		Endnotes:   endnotes,
+		Headers:    headers,
+		Footers:    footers,
+		Sections:   sections,
+		Comments:   comments,
+		Revisions:  body.revisions,
+		Properties: properties,
+		variant:    readVariant(pkg, mainPart, VariantDocx),
+		vbaProject: hasVbaProject(pkg, mainPart)}, nil
 }
@@ -0,0 +1,238 @@
+package format
+
+import (
+	"encoding/xml"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"strings"
+)
+
+// docxBody is the content of the body of a wordprocessingml part.
+type docxBody struct {
+	paragraphs        []string
+	tables            []Table
+	commentAnchors    map[string]string
+	revisions         []Revision
+	links             []Link
+	sections          []Section
+	sectionReferences []sectionReferences
+}
+
+// docxBodyParser walks the tokens of a wordprocessingml part and collects
+// its content. Paragraphs are kept on a stack as they can be nested (e.g. the
+// paragraphs of a text box are inside a run of the enclosing paragraph) and so
+// are the tables (tables can be nested in table cells).
+type docxBodyParser struct {
+	body          docxBody
+	mode          RevisionMode
+	inlineLinks   bool
+	pkg           *opc.Package
+	part          string
+	paragraphs    []*strings.Builder
+	tables        []*Table
+	anchors       map[string]*strings.Builder
+	revisions     []*Revision
+	openRevisions []*Revision
+	mark          revisionMark
+	openLinks     []*openLink
+	linkLocations []linkLocation
+	sectionDepth  int
+	runDepth      int
+	inText        bool
+}
+
+// parseDocxBody parses the given wordprocessingml xml (document.xml or any
+// other part with the same structure). Hyperlinks are resolved through the
+// relationships of the given part of the package. The tracked changes and the
+// links are reflected in the text according to the settings.
+func parseDocxBody(textXml string, pkg *opc.Package, part string, settings options) (*docxBody, error) {
+	var (
+		contents = strings.NewReader(textXml)
+		decoder  = xml.NewDecoder(contents)
+		parser   = docxBodyParser{
+			mode:        settings.revisions,
+			inlineLinks: settings.inlineLinks,
+			pkg:         pkg,
+			part:        part,
+		}
+	)
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, XmlErrorAt(decoder, err)
+		}
+
+		parser.token(token)
+	}
+
+	parser.body.revisions = parser.revisionList()
+	parser.resolveLinkPositions()
+	return &parser.body, nil
+}
+
+// token processes the next token of the part.
+func (p *docxBodyParser) token(token xml.Token) {
+	switch t := token.(type) {
+	case xml.CharData:
+		if p.inText {
+			p.write(string(t))
+		}
+	case xml.StartElement:
+		p.start(t)
+	case xml.EndElement:
+		p.end(t)
+	default:
+	}
+}
+
+func (p *docxBodyParser) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "p":
+		p.paragraphs = append(p.paragraphs, &strings.Builder{})
+
+		for _, anchor := range p.anchors {
+			if anchor.Len() > 0 {
+				anchor.WriteString("\n")
+			}
+		}
+	case "hyperlink":
+		p.startLink(t)
+	case "sectPr":
+		p.startSection()
+	case "headerReference", "footerReference", "pgSz":
+		p.sectionProperty(t)
+	case "commentRangeStart":
+		p.startAnchor(AttrValue(t, "id"))
+	case "commentRangeEnd":
+		p.endAnchor(AttrValue(t, "id"))
+	case "tbl":
+		p.startTable()
+	case "tr":
+		p.startRow()
+	case "tc":
+		p.startCell()
+	case "gridSpan", "vMerge":
+		p.cellProperty(t.Name.Local, AttrValue(t, "val"))
+	case "r":
+		p.runDepth++
+	case "ins", "del", "moveFrom", "moveTo":
+		p.startRevision(t, revisionKinds[t.Name.Local])
+	case "t", "delText":
+		p.inText = p.runDepth > 0
+	case "tab":
+		// Tab stop definitions in the paragraph properties are also called
+		// tab, only the ones inside runs are actual characters.
+		if p.runDepth > 0 {
+			p.write("\t")
+		}
+	case "br", "cr":
+		if p.runDepth > 0 {
+			p.write("\n")
+		}
+	case "noBreakHyphen":
+		p.write("\u2011")
+	case "softHyphen":
+		p.write("\u00ad")
+	}
+}
+
+func (p *docxBodyParser) end(t xml.EndElement) {
+	switch t.Name.Local {
+	case "p":
+		p.annotate(unchanged)
+
+		if last := len(p.paragraphs) - 1; last >= 0 {
+			paragraph := p.paragraphs[last].String()
+			p.closeLinkParagraph(p.paragraphs[last], len(p.body.paragraphs))
+			p.body.paragraphs = append(p.body.paragraphs, paragraph)
+			p.paragraphs = p.paragraphs[:last]
+
+			if cell := p.currentCell(); cell != nil {
+				cell.Paragraphs = append(cell.Paragraphs, paragraph)
+			}
+		}
+	case "hyperlink":
+		p.endLink()
+	case "sectPr":
+		p.endSection()
+	case "tbl":
+		p.endTable()
+	case "tc":
+		p.endCell()
+	case "r":
+		if p.runDepth > 0 {
+			p.runDepth--
+		}
+	case "ins", "del", "moveFrom", "moveTo":
+		p.endRevision()
+	case "t", "delText":
+		p.inText = false
+	}
+}
+
+// write appends text to the innermost open paragraph and to the comment
+// ranges that are open unless the text is hidden by the revision mode. Text
+// outside of paragraphs is dropped.
+func (p *docxBodyParser) write(text string) {
+	if len(p.paragraphs) == 0 {
+		return
+	}
+
+	for _, r := range p.openRevisions {
+		r.Text += text
+	}
+
+	mark := p.currentMark()
+	if !p.visible(mark) {
+		return
+	}
+
+	for _, l := range p.openLinks {
+		l.text.WriteString(text)
+	}
+
+	p.annotate(mark)
+	p.writeRaw(text)
+}
+
+// writeRaw appends text to the innermost open paragraph and to the comment
+// ranges that are open.
+func (p *docxBodyParser) writeRaw(text string) {
+	if last := len(p.paragraphs) - 1; last >= 0 {
+		p.paragraphs[last].WriteString(text)
+
+		for _, anchor := range p.anchors {
+			anchor.WriteString(text)
+		}
+	}
+}
+
+// startAnchor starts collecting the text the comment with the given id refers
+// to.
+func (p *docxBodyParser) startAnchor(id string) {
+	if p.anchors == nil {
+		p.anchors = map[string]*strings.Builder{}
+	}
+
+	p.anchors[id] = &strings.Builder{}
+}
+
+// endAnchor finishes the text range of the comment with the given id.
+func (p *docxBodyParser) endAnchor(id string) {
+	anchor, found := p.anchors[id]
+	if !found {
+		return
+	}
+
+	if p.body.commentAnchors == nil {
+		p.body.commentAnchors = map[string]string{}
+	}
+
+	p.body.commentAnchors[id] = anchor.String()
+	delete(p.anchors, id)
+}
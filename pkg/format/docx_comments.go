@@ -0,0 +1,196 @@
+package format
+
+import (
+	"encoding/xml"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	commentsRelationshipType         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+	commentsExtendedRelationshipType = "http://schemas.microsoft.com/office/2011/relationships/commentsExtended"
+)
+
+// Comment is a reviewer's comment in a docx document. Text is the content of
+// the comment and Anchor is the text of the document the comment refers to
+// (empty if the comment is not attached to a range of text). ParentId is the
+// Id of the comment this one replies to (empty for comments that start a
+// thread) and Resolved tells whether the comment was marked as done. The
+// thread and resolution information is only available for documents that
+// have a commentsExtended.xml part.
+type Comment struct {
+	Id       string
+	Author   string
+	Initials string
+	Date     time.Time
+	Text     string
+	Anchor   string
+	ParentId string
+	Resolved bool
+}
+
+// commentExtension is an entry of commentsExtended.xml.
+type commentExtension struct {
+	parentParaId string
+	done         bool
+}
+
+// readComments reads the comments of the document through the relationships
+// of its main part. Documents without comments yield an empty list. If only
+// the extended comments part (the replies and the resolved state) cannot be
+// read, the comments are returned without that information.
+func readComments(
+	reader archive.ZipData, pkg *opc.Package, mainPart string, anchors map[string]string,
+) ([]Comment, error) {
+	commentsRelationships := pkg.RelationshipsOfType(mainPart, commentsRelationshipType)
+	if len(commentsRelationships) == 0 || commentsRelationships[0].External {
+		return []Comment{}, nil
+	}
+
+	commentsPart := commentsRelationships[0].TargetPart()
+
+	commentsXml, err := ReadXml(reader, commentsPart)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, paraIds, err := commentsFromXml(commentsXml)
+	if err != nil {
+		return nil, InPart(err, commentsPart)
+	}
+
+	for i := range comments {
+		comments[i].Anchor = anchors[comments[i].Id]
+	}
+
+	extendedRelationships := pkg.RelationshipsOfType(mainPart, commentsExtendedRelationshipType)
+	if len(extendedRelationships) == 0 || extendedRelationships[0].External {
+		return comments, nil
+	}
+
+	extendedPart := extendedRelationships[0].TargetPart()
+
+	extendedXml, err := ReadXml(reader, extendedPart)
+	if isLimitError(err) {
+		return nil, err
+	} else if err != nil {
+		// The replies and the resolved state are left unset.
+		return comments, nil
+	}
+
+	extensions, err := commentExtensionsFromXml(extendedXml)
+	if err != nil {
+		return comments, nil
+	}
+
+	commentIds := map[string]string{}
+	for i, paraId := range paraIds {
+		commentIds[paraId] = comments[i].Id
+	}
+
+	for i, paraId := range paraIds {
+		if extension, found := extensions[paraId]; found {
+			comments[i].ParentId = commentIds[extension.parentParaId]
+			comments[i].Resolved = extension.done
+		}
+	}
+
+	return comments, nil
+}
+
+// commentsFromXml parses comments.xml. Besides the comments it returns the
+// paragraph id (w14:paraId) of the last paragraph of each comment, as that is
+// what commentsExtended.xml refers to.
+func commentsFromXml(commentsXml string) (comments []Comment, paraIds []string, err error) {
+	var (
+		reader  = strings.NewReader(commentsXml)
+		decoder = xml.NewDecoder(reader)
+		comment *Comment
+		parser  *docxBodyParser
+		paraId  string
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "comment" {
+				date, _ := time.Parse(time.RFC3339, AttrValue(t, "date"))
+				comment = &Comment{
+					Id:       AttrValue(t, "id"),
+					Author:   AttrValue(t, "author"),
+					Initials: AttrValue(t, "initials"),
+					Date:     date,
+				}
+				parser = &docxBodyParser{}
+				paraId = ""
+				continue
+			} else if t.Name.Local == "p" && parser != nil {
+				paraId = AttrValue(t, "paraId")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "comment" && comment != nil {
+				comment.Text = strings.Join(parser.body.paragraphs, "\n")
+				comments = append(comments, *comment)
+				paraIds = append(paraIds, paraId)
+				comment, parser = nil, nil
+				continue
+			}
+		default:
+		}
+
+		if parser != nil {
+			parser.token(token)
+		}
+	}
+
+	return
+}
+
+// commentExtensionsFromXml parses commentsExtended.xml into a map keyed by the
+// paragraph ids of the comments.
+func commentExtensionsFromXml(extendedXml string) (extensions map[string]commentExtension, err error) {
+	var (
+		reader  = strings.NewReader(extendedXml)
+		decoder = xml.NewDecoder(reader)
+	)
+
+	extensions = map[string]commentExtension{}
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "commentEx" {
+				done := AttrValue(t, "done")
+				extensions[AttrValue(t, "paraId")] = commentExtension{
+					parentParaId: AttrValue(t, "paraIdParent"),
+					done:         done == "1" || done == "true",
+				}
+			}
+		default:
+		}
+	}
+
+	return
+}
@@ -0,0 +1,125 @@
+package format
+
+import (
+	"encoding/xml"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"strings"
+)
+
+// Link is a hyperlink of a docx document. Text is the text the link is
+// attached to and Target is where it points: an URL for external links or
+// the name of a bookmark for links within the document (in which case
+// Internal is true). Position is the byte offset of the link text in the Text
+// of the Docx.
+type Link struct {
+	Text     string
+	Target   string
+	Internal bool
+	Position int
+}
+
+// linkLocation tells where the text of a link starts: in which paragraph (its
+// builder while it is open, its index once it is closed) and at which offset.
+type linkLocation struct {
+	paragraph *strings.Builder
+	index     int
+	offset    int
+}
+
+// openLink is a hyperlink whose end has not been reached yet.
+type openLink struct {
+	link int
+	text strings.Builder
+}
+
+// relationship returns the relationship of the parsed part with the given id.
+// Parsers without a package (e.g. the ones of the comments) have no
+// relationships.
+func (p *docxBodyParser) relationship(id string) (opc.Relationship, bool) {
+	if p.pkg == nil {
+		return opc.Relationship{}, false
+	}
+
+	return p.pkg.Relationship(p.part, id)
+}
+
+func (p *docxBodyParser) startLink(t xml.StartElement) {
+	var (
+		link   Link
+		anchor = AttrValue(t, "anchor")
+	)
+
+	if r, found := p.relationship(RelationshipIdAttr(t)); found {
+		link.Target = r.Target
+		link.Internal = !r.External
+
+		if anchor != "" {
+			link.Target += "#" + anchor
+		}
+	} else if anchor != "" {
+		link.Target = anchor
+		link.Internal = true
+	}
+
+	location := linkLocation{index: -1}
+	if last := len(p.paragraphs) - 1; last >= 0 {
+		location.paragraph = p.paragraphs[last]
+		location.offset = p.paragraphs[last].Len()
+	}
+
+	p.body.links = append(p.body.links, link)
+	p.linkLocations = append(p.linkLocations, location)
+	p.openLinks = append(p.openLinks, &openLink{link: len(p.body.links) - 1})
+}
+
+func (p *docxBodyParser) endLink() {
+	last := len(p.openLinks) - 1
+	if last < 0 {
+		return
+	}
+
+	open := p.openLinks[last]
+	p.openLinks = p.openLinks[:last]
+
+	link := &p.body.links[open.link]
+	link.Text = open.text.String()
+
+	if p.inlineLinks && link.Target != "" && p.visible(p.currentMark()) {
+		if link.Internal {
+			p.writeRaw(" <#" + link.Target + ">")
+		} else {
+			p.writeRaw(" <" + link.Target + ">")
+		}
+	}
+}
+
+// closeLinkParagraph records the index of the paragraph that is being closed
+// for the links that start in it.
+func (p *docxBodyParser) closeLinkParagraph(paragraph *strings.Builder, index int) {
+	for i := range p.linkLocations {
+		if p.linkLocations[i].paragraph == paragraph {
+			p.linkLocations[i].paragraph = nil
+			p.linkLocations[i].index = index
+		}
+	}
+}
+
+// resolveLinkPositions turns the paragraph indices and offsets of the links
+// into offsets in the text that is built by joining the paragraphs with
+// newlines.
+func (p *docxBodyParser) resolveLinkPositions() {
+	starts := make([]int, len(p.body.paragraphs))
+	position := 0
+
+	for i, paragraph := range p.body.paragraphs {
+		starts[i] = position
+		position += len(paragraph) + 1
+	}
+
+	for i, location := range p.linkLocations {
+		if location.index >= 0 && location.index < len(starts) {
+			p.body.links[i].Position = starts[location.index] + location.offset
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package format
+
+import (
+	"encoding/xml"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"strings"
+)
+
+const (
+	footnotesRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes"
+	endnotesRelationshipType  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/endnotes"
+)
+
+// Note is a footnote or an endnote of a docx document. Id is the w:id of the
+// note: the reference mark of the note in the body (w:footnoteReference or
+// w:endnoteReference) has the same id. Text is the content of the note
+// without the leading reference mark and the whitespace following it.
+type Note struct {
+	Id   string
+	Text string
+}
+
+// readNotes reads the notes from the part the main part refers to with the
+// given relationship type. The element name is either footnote or endnote.
+// Documents without such notes yield an empty list.
+func readNotes(
+	reader archive.ZipData, pkg *opc.Package, mainPart string,
+	relationshipType string, elementName string, mode RevisionMode,
+) ([]Note, error) {
+	notesRelationships := pkg.RelationshipsOfType(mainPart, relationshipType)
+	if len(notesRelationships) == 0 || notesRelationships[0].External {
+		return []Note{}, nil
+	}
+
+	notesXml, err := ReadXml(reader, notesRelationships[0].TargetPart())
+	if err != nil {
+		return nil, err
+	}
+
+	return notesFromXml(notesXml, elementName, mode)
+}
+
+// notesFromXml parses footnotes.xml or endnotes.xml. The separator pseudo-notes
+// (the lines separating the notes from the body) are skipped.
+func notesFromXml(notesXml string, elementName string, mode RevisionMode) (notes []Note, err error) {
+	var (
+		reader  = strings.NewReader(notesXml)
+		decoder = xml.NewDecoder(reader)
+		note    *Note
+		parser  *docxBodyParser
+	)
+
+	notes = []Note{}
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == elementName {
+				switch AttrValue(t, "type") {
+				case "separator", "continuationSeparator", "continuationNotice":
+					note = nil
+				default:
+					note = &Note{Id: AttrValue(t, "id")}
+				}
+				parser = &docxBodyParser{mode: mode}
+				continue
+			}
+		case xml.EndElement:
+			if t.Name.Local == elementName && parser != nil {
+				if note != nil {
+					note.Text = strings.TrimSpace(strings.Join(parser.body.paragraphs, "\n"))
+					notes = append(notes, *note)
+				}
+				note, parser = nil, nil
+				continue
+			}
+		default:
+		}
+
+		if parser != nil {
+			parser.token(token)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,189 @@
+package format
+
+import (
+	"encoding/xml"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"time"
+)
+
+// RevisionMode tells how the tracked changes (revisions) of a docx document
+// are reflected in its extracted text.
+type RevisionMode int
+
+const (
+	// RevisionsAccepted produces the text as if every change was accepted:
+	// insertions are kept, deletions are dropped and moved text only appears
+	// at its new location.
+	RevisionsAccepted RevisionMode = iota
+	// RevisionsOriginal produces the text as it was before the changes:
+	// deletions are kept, insertions are dropped and moved text only appears
+	// at its original location.
+	RevisionsOriginal
+	// RevisionsAnnotated keeps both the inserted and the deleted text and
+	// marks them: insertions are enclosed in {+ and +}, deletions in [- and
+	// -] (moves are marked as a deletion and an insertion).
+	RevisionsAnnotated
+)
+
+// RevisionKind is the kind of a tracked change.
+type RevisionKind int
+
+const (
+	// Insertion is text inserted by the change (w:ins).
+	Insertion RevisionKind = iota
+	// Deletion is text deleted by the change (w:del).
+	Deletion
+	// MoveFrom is the original location of moved text (w:moveFrom).
+	MoveFrom
+	// MoveTo is the new location of moved text (w:moveTo).
+	MoveTo
+)
+
+// String returns the name of the wordprocessingml element of the kind.
+func (k RevisionKind) String() string {
+	switch k {
+	case Insertion:
+		return "ins"
+	case Deletion:
+		return "del"
+	case MoveFrom:
+		return "moveFrom"
+	case MoveTo:
+		return "moveTo"
+	default:
+		return "unknown"
+	}
+}
+
+// Revision is a tracked change of a docx document. Text is the text that was
+// inserted, deleted or moved by the change. Changes that only affect
+// formatting or paragraph marks are not listed.
+type Revision struct {
+	Id     string
+	Kind   RevisionKind
+	Author string
+	Date   time.Time
+	Text   string
+}
+
+// removes tells whether the change removes its content from the accepted
+// text.
+func (k RevisionKind) removes() bool {
+	return k == Deletion || k == MoveFrom
+}
+
+// revisionKinds maps the names of the revision elements to their kinds.
+var revisionKinds = map[string]RevisionKind{
+	"ins":      Insertion,
+	"del":      Deletion,
+	"moveFrom": MoveFrom,
+	"moveTo":   MoveTo,
+}
+
+// revisionMark is the state of the text being written with respect to the
+// open revisions.
+type revisionMark int
+
+const (
+	unchanged revisionMark = iota
+	inserted
+	deleted
+)
+
+func (p *docxBodyParser) startRevision(t xml.StartElement, kind RevisionKind) {
+	date, _ := time.Parse(time.RFC3339, AttrValue(t, "date"))
+	revision := &Revision{
+		Id:     AttrValue(t, "id"),
+		Kind:   kind,
+		Author: AttrValue(t, "author"),
+		Date:   date,
+	}
+
+	p.revisions = append(p.revisions, revision)
+	p.openRevisions = append(p.openRevisions, revision)
+}
+
+func (p *docxBodyParser) endRevision() {
+	if last := len(p.openRevisions) - 1; last >= 0 {
+		p.openRevisions = p.openRevisions[:last]
+	}
+}
+
+// currentMark returns the state of the text that is written at the moment:
+// whether it is part of an insertion, a deletion or neither. Text that was
+// inserted and then deleted counts as deleted.
+func (p *docxBodyParser) currentMark() revisionMark {
+	mark := unchanged
+
+	for _, r := range p.openRevisions {
+		if r.Kind.removes() {
+			return deleted
+		}
+
+		mark = inserted
+	}
+
+	return mark
+}
+
+// visible tells whether the text with the given mark is part of the text in
+// the chosen revision mode.
+func (p *docxBodyParser) visible(mark revisionMark) bool {
+	switch p.mode {
+	case RevisionsOriginal:
+		return !p.insertedBefore()
+	case RevisionsAnnotated:
+		return true
+	default:
+		return mark != deleted
+	}
+}
+
+// insertedBefore tells whether any of the open revisions is an insertion,
+// i.e. the text did not exist in the original document.
+func (p *docxBodyParser) insertedBefore() bool {
+	for _, r := range p.openRevisions {
+		if !r.Kind.removes() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// annotate writes the markers of the annotated mode when the state of the text
+// changes from the previous mark to the given one.
+func (p *docxBodyParser) annotate(mark revisionMark) {
+	if p.mode != RevisionsAnnotated || mark == p.mark {
+		return
+	}
+
+	switch p.mark {
+	case inserted:
+		p.writeRaw("+}")
+	case deleted:
+		p.writeRaw("-]")
+	}
+
+	switch mark {
+	case inserted:
+		p.writeRaw("{+")
+	case deleted:
+		p.writeRaw("[-")
+	}
+
+	p.mark = mark
+}
+
+// revisionList returns the revisions that changed text, in document order.
+func (p *docxBodyParser) revisionList() []Revision {
+	revisions := []Revision{}
+
+	for _, r := range p.revisions {
+		if r.Text != "" {
+			revisions = append(revisions, *r)
+		}
+	}
+
+	return revisions
+}
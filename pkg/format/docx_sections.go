@@ -0,0 +1,245 @@
+package format
+
+import (
+	"encoding/xml"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"strconv"
+	"strings"
+)
+
+// Orientation is the orientation of the pages of a section.
+type Orientation int
+
+const (
+	// Portrait is the orientation of pages that are taller than wide.
+	Portrait Orientation = iota
+	// Landscape is the orientation of pages that are wider than tall.
+	Landscape
+)
+
+// HeaderFooter contains the texts of the three kinds of headers (or footers)
+// a section can have: Default is used on most pages, First on the first page
+// of the section and Even on the even pages. The kinds that are not set are
+// empty strings. Whether First and Even are actually shown depends on the
+// settings of the document.
+type HeaderFooter struct {
+	Default string
+	First   string
+	Even    string
+}
+
+// Section is a section of a docx document. Headers and Footers contain the
+// headers and footers of the section (the ones that are not given by the
+// section itself are inherited from the previous section), PageWidth and
+// PageHeight give the page size in twentieths of a point (0 if it is not
+// given) and Orientation tells the page orientation. LastParagraph is the
+// index of the last paragraph of the section in the Paragraphs of the Docx.
+type Section struct {
+	Headers       HeaderFooter
+	Footers       HeaderFooter
+	PageWidth     int
+	PageHeight    int
+	Orientation   Orientation
+	LastParagraph int
+}
+
+// sectionReferences holds the relationship ids of the headers and footers of
+// a section keyed by their type (default, first or even).
+type sectionReferences struct {
+	headers map[string]string
+	footers map[string]string
+}
+
+func (p *docxBodyParser) startSection() {
+	p.sectionDepth++
+	if p.sectionDepth > 1 {
+		// Section properties nested in sectPrChange are the ones before
+		// a tracked change, they are ignored.
+		return
+	}
+
+	p.body.sections = append(p.body.sections, Section{})
+	p.body.sectionReferences = append(p.body.sectionReferences, sectionReferences{
+		headers: map[string]string{},
+		footers: map[string]string{},
+	})
+}
+
+func (p *docxBodyParser) endSection() {
+	p.sectionDepth--
+	if p.sectionDepth > 0 || len(p.body.sections) == 0 {
+		return
+	}
+
+	// Sections end with the paragraph that contains their properties, the
+	// last section of the body has its properties after the last paragraph.
+	section := &p.body.sections[len(p.body.sections)-1]
+	if len(p.paragraphs) > 0 {
+		section.LastParagraph = len(p.body.paragraphs)
+	} else {
+		section.LastParagraph = len(p.body.paragraphs) - 1
+	}
+}
+
+// sectionProperty processes the elements of the section properties the
+// Section type reflects.
+func (p *docxBodyParser) sectionProperty(t xml.StartElement) {
+	if p.sectionDepth != 1 {
+		return
+	}
+
+	var (
+		last       = len(p.body.sections) - 1
+		section    = &p.body.sections[last]
+		references = p.body.sectionReferences[last]
+		kind       = AttrValue(t, "type")
+	)
+
+	if kind == "" {
+		kind = "default"
+	}
+
+	switch t.Name.Local {
+	case "headerReference":
+		references.headers[kind] = RelationshipIdAttr(t)
+	case "footerReference":
+		references.footers[kind] = RelationshipIdAttr(t)
+	case "pgSz":
+		section.PageWidth, _ = strconv.Atoi(AttrValue(t, "w"))
+		section.PageHeight, _ = strconv.Atoi(AttrValue(t, "h"))
+
+		orientation := AttrValue(t, "orient")
+		if orientation == "landscape" || (orientation == "" && section.PageWidth > section.PageHeight) {
+			section.Orientation = Landscape
+		}
+	}
+}
+
+// headerFooterReader reads and caches the texts of header and footer parts.
+type headerFooterReader struct {
+	reader   archive.ZipData
+	pkg      *opc.Package
+	mainPart string
+	settings options
+	texts    map[string]string
+	parts    []string
+}
+
+// text returns the text of the header or footer the main part refers to with
+// the given relationship id. Parts that are missing or cannot be parsed are
+// skipped (their text is empty), only exceeding the limits of the document is
+// reported as an error.
+func (h *headerFooterReader) text(id string) (string, error) {
+	r, found := h.pkg.Relationship(h.mainPart, id)
+	if !found || r.External {
+		return "", nil
+	}
+
+	part := r.TargetPart()
+	if text, found := h.texts[part]; found {
+		return text, nil
+	}
+
+	partXml, err := ReadXml(h.reader, part)
+	if err != nil {
+		return "", h.skip(part, err)
+	}
+
+	body, err := parseDocxBody(partXml, h.pkg, part, h.settings)
+	if err != nil {
+		return "", h.skip(part, err)
+	}
+
+	text := strings.Join(body.paragraphs, "\n")
+	h.texts[part] = text
+	h.parts = append(h.parts, part)
+
+	return text, nil
+}
+
+// skip records the part that cannot be read as one without text. Limit errors
+// are returned as they are, every other error is dropped.
+func (h *headerFooterReader) skip(part string, err error) error {
+	if isLimitError(err) {
+		return err
+	}
+
+	h.texts[part] = ""
+	return nil
+}
+
+// resolve fills in the texts of the given kinds of headers or footers of the
+// section. The kinds the section doesn't refer to are inherited from the
+// previous one.
+func (h *headerFooterReader) resolve(
+	references map[string]string, previous HeaderFooter,
+) (result HeaderFooter, err error) {
+	result = previous
+
+	for _, k := range []struct {
+		kind   string
+		target *string
+	}{
+		{"default", &result.Default},
+		{"first", &result.First},
+		{"even", &result.Even},
+	} {
+		if id, found := references[k.kind]; found {
+			if *k.target, err = h.text(id); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// readSections resolves the headers and footers of the sections of the body.
+// Besides the sections it returns the texts of every header and every footer
+// in the order they are first referred to.
+func readSections(
+	reader archive.ZipData, pkg *opc.Package, mainPart string, body *docxBody, settings options,
+) (sections []Section, headers []string, footers []string, err error) {
+	var (
+		headerReader = &headerFooterReader{
+			reader: reader, pkg: pkg, mainPart: mainPart, settings: settings,
+			texts: map[string]string{},
+		}
+		footerReader = &headerFooterReader{
+			reader: reader, pkg: pkg, mainPart: mainPart, settings: settings,
+			texts: map[string]string{},
+		}
+		previous Section
+	)
+
+	sections = []Section{}
+
+	for i, section := range body.sections {
+		references := body.sectionReferences[i]
+
+		if section.Headers, err = headerReader.resolve(references.headers, previous.Headers); err != nil {
+			return
+		}
+
+		if section.Footers, err = footerReader.resolve(references.footers, previous.Footers); err != nil {
+			return
+		}
+
+		sections = append(sections, section)
+		previous = section
+	}
+
+	headers, footers = []string{}, []string{}
+
+	for _, part := range headerReader.parts {
+		headers = append(headers, headerReader.texts[part])
+	}
+
+	for _, part := range footerReader.parts {
+		footers = append(footers, footerReader.texts[part])
+	}
+
+	return
+}
@@ -0,0 +1,134 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VerticalMerge tells whether a table cell is part of a vertically merged
+// group of cells.
+type VerticalMerge int
+
+const (
+	// NoMerge marks cells that are not merged vertically.
+	NoMerge VerticalMerge = iota
+	// MergeRestart marks the first (topmost) cell of a vertically merged
+	// group. It holds the content of the whole group.
+	MergeRestart
+	// MergeContinue marks the cells that continue the merged group started
+	// above them. They are usually empty.
+	MergeContinue
+)
+
+// Table is a table of a docx document. Position is the index of the first
+// paragraph of the table in the Paragraphs of the Docx and ParagraphCount is
+// the number of paragraphs the table (including its nested tables) spans
+// there, i.e. the table sits between Paragraphs[Position-1] and
+// Paragraphs[Position+ParagraphCount].
+type Table struct {
+	Rows           []TableRow
+	Position       int
+	ParagraphCount int
+}
+
+// TableRow is a row of a Table.
+type TableRow struct {
+	Cells []TableCell
+}
+
+// TableCell is a cell of a TableRow. Text contains the paragraphs of the cell
+// separated by newlines, Paragraphs lists them one by one. GridSpan is the
+// number of grid columns the cell spans horizontally (1 for regular cells)
+// and VerticalMerge tells its role in a vertically merged group of cells.
+// Tables contains the tables nested in the cell.
+type TableCell struct {
+	Text          string
+	Paragraphs    []string
+	GridSpan      int
+	VerticalMerge VerticalMerge
+	Tables        []Table
+}
+
+// startTable opens a new (possibly nested) table.
+func (p *docxBodyParser) startTable() {
+	p.tables = append(p.tables, &Table{Position: len(p.body.paragraphs)})
+}
+
+// endTable closes the innermost table and adds it either to the enclosing
+// cell or to the tables of the body.
+func (p *docxBodyParser) endTable() {
+	last := len(p.tables) - 1
+	if last < 0 {
+		return
+	}
+
+	table := p.tables[last]
+	table.ParagraphCount = len(p.body.paragraphs) - table.Position
+	p.tables = p.tables[:last]
+
+	if cell := p.currentCell(); cell != nil {
+		cell.Tables = append(cell.Tables, *table)
+	} else {
+		p.body.tables = append(p.body.tables, *table)
+	}
+}
+
+func (p *docxBodyParser) startRow() {
+	if last := len(p.tables) - 1; last >= 0 {
+		p.tables[last].Rows = append(p.tables[last].Rows, TableRow{})
+	}
+}
+
+func (p *docxBodyParser) startCell() {
+	last := len(p.tables) - 1
+	if last < 0 || len(p.tables[last].Rows) == 0 {
+		return
+	}
+
+	row := &p.tables[last].Rows[len(p.tables[last].Rows)-1]
+	row.Cells = append(row.Cells, TableCell{GridSpan: 1})
+}
+
+func (p *docxBodyParser) endCell() {
+	if cell := p.currentCell(); cell != nil {
+		cell.Text = strings.Join(cell.Paragraphs, "\n")
+	}
+}
+
+// currentCell returns the innermost open cell or nil if there is none.
+func (p *docxBodyParser) currentCell() *TableCell {
+	last := len(p.tables) - 1
+	if last < 0 {
+		return nil
+	}
+
+	rows := p.tables[last].Rows
+	if len(rows) == 0 || len(rows[len(rows)-1].Cells) == 0 {
+		return nil
+	}
+
+	cells := rows[len(rows)-1].Cells
+	return &cells[len(cells)-1]
+}
+
+// cellProperty processes the gridSpan and vMerge properties of the current
+// cell.
+func (p *docxBodyParser) cellProperty(name string, value string) {
+	cell := p.currentCell()
+	if cell == nil {
+		return
+	}
+
+	switch name {
+	case "gridSpan":
+		if span, err := strconv.Atoi(value); err == nil && span > 0 {
+			cell.GridSpan = span
+		}
+	case "vMerge":
+		if value == "restart" {
+			cell.VerticalMerge = MergeRestart
+		} else {
+			cell.VerticalMerge = MergeContinue
+		}
+	}
+}
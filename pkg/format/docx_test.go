@@ -1,8 +1,11 @@
 package format
 
 import (
+	"bytes"
+	"io/ioutil"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestMakingDocxGoodPath(t *testing.T) {
@@ -37,7 +40,7 @@ func TestReadingDocxText(t *testing.T) {
 	}
 
 	text = "tourism in Jutland."
-	if !strings.HasSuffix(doc.Text, text) {
+	if !strings.HasSuffix(strings.TrimSpace(doc.Text), text) {
 		t.Errorf("Expected the text to end with: \"%s\"", text)
 	}
 
@@ -45,8 +48,8 @@ func TestReadingDocxText(t *testing.T) {
 		t.Errorf("Expected to have 7 links, has: %d", len(doc.Links))
 	} else {
 		linkFragment := "Denmark_Region"
-		if !strings.Contains(doc.Links[0], linkFragment) {
-			t.Errorf("First link (%s) expected to contain: %s", doc.Links[0], linkFragment)
+		if !strings.Contains(doc.Links[0].Target, linkFragment) {
+			t.Errorf("First link (%s) expected to contain: %s", doc.Links[0].Target, linkFragment)
 		}
 	}
 
@@ -54,8 +57,8 @@ func TestReadingDocxText(t *testing.T) {
 		t.Errorf("Expected to have one footnote, has %d", len(doc.Footnotes))
 	} else {
 		footnote := "This is a footnote."
-		if doc.Footnotes[0] != footnote {
-			t.Errorf("Expected the first footnote to be: %s, was: %s", footnote, doc.Footnotes[0])
+		if doc.Footnotes[0].Text != footnote {
+			t.Errorf("Expected the first footnote to be: %s, was: %s", footnote, doc.Footnotes[0].Text)
 		}
 	}
 
@@ -102,7 +105,7 @@ func TestReadingDocxTextFromUrl(t *testing.T) {
 	}
 
 	text = "tourism in Jutland."
-	if !strings.HasSuffix(doc.Text, text) {
+	if !strings.HasSuffix(strings.TrimSpace(doc.Text), text) {
 		t.Errorf("Expected the text to end with: \"%s\"", text)
 	}
 
@@ -110,8 +113,8 @@ func TestReadingDocxTextFromUrl(t *testing.T) {
 		t.Errorf("Expected to have 7 links, has: %d", len(doc.Links))
 	} else {
 		linkFragment := "Denmark_Region"
-		if !strings.Contains(doc.Links[0], linkFragment) {
-			t.Errorf("First link (%s) expected to contain: %s", doc.Links[0], linkFragment)
+		if !strings.Contains(doc.Links[0].Target, linkFragment) {
+			t.Errorf("First link (%s) expected to contain: %s", doc.Links[0].Target, linkFragment)
 		}
 	}
 
@@ -119,8 +122,8 @@ func TestReadingDocxTextFromUrl(t *testing.T) {
 		t.Errorf("Expected to have one footnote, has %d", len(doc.Footnotes))
 	} else {
 		footnote := "This is a footnote."
-		if doc.Footnotes[0] != footnote {
-			t.Errorf("Expected the first footnote to be: %s, was: %s", footnote, doc.Footnotes[0])
+		if doc.Footnotes[0].Text != footnote {
+			t.Errorf("Expected the first footnote to be: %s, was: %s", footnote, doc.Footnotes[0].Text)
 		}
 	}
 
@@ -151,3 +154,385 @@ func TestMakingDocxBadUrl(t *testing.T) {
 		t.Errorf("Expected to fail to open %s successfully", url)
 	}
 }
+
+func TestReadingDocxFromMemory(t *testing.T) {
+	content, err := ioutil.ReadFile("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the example document: %s", err.Error())
+	}
+
+	fromPath, _ := MakeDocx("../../test_data/example.docx")
+
+	fromBytes, err := MakeDocxFromBytes(content)
+	if err != nil || fromBytes.Text != fromPath.Text {
+		t.Errorf("Expected to read the same text from bytes: %v", err)
+	}
+
+	fromReader, err := MakeDocxFromReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil || fromReader.Text != fromPath.Text {
+		t.Errorf("Expected to read the same text from a reader: %v", err)
+	}
+
+	fsys := fstest.MapFS{"docs/example.docx": &fstest.MapFile{Data: content}}
+
+	fromFS, err := MakeDocxFromFS(fsys, "docs/example.docx")
+	if err != nil || fromFS.Text != fromPath.Text {
+		t.Errorf("Expected to read the same text from a file system: %v", err)
+	}
+
+	if _, err = MakeDocxFromBytes([]byte("not a zip archive")); err == nil {
+		t.Errorf("Expected an error for data that is not a document")
+	}
+}
+
+func TestReadingDocxParagraphs(t *testing.T) {
+	path := "../../test_data/paragraphs.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully", path)
+	}
+
+	expected := []string{
+		"Heading",
+		"Name:\tValue",
+		"First line\nsecond line\nthird line",
+		"e\u2011mail and hy\u00adphen",
+	}
+
+	if len(doc.Paragraphs) != len(expected) {
+		t.Fatalf("Expected to have %d paragraphs, has: %d", len(expected), len(doc.Paragraphs))
+	}
+
+	for i, paragraph := range expected {
+		if doc.Paragraphs[i] != paragraph {
+			t.Errorf("Expected paragraph %d to be: %q, was: %q", i, paragraph, doc.Paragraphs[i])
+		}
+	}
+
+	text := strings.Join(expected, "\n")
+	if doc.Text != text {
+		t.Errorf("Expected the text to be: %q, was: %q", text, doc.Text)
+	}
+}
+
+func TestReadingDocxTables(t *testing.T) {
+	path := "../../test_data/tables.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully", path)
+	}
+
+	if len(doc.Tables) != 1 {
+		t.Fatalf("Expected to have one table, has: %d", len(doc.Tables))
+	}
+
+	table := doc.Tables[0]
+	if doc.Paragraphs[table.Position-1] != "Before the table" ||
+		doc.Paragraphs[table.Position+table.ParagraphCount] != "After the table" {
+		t.Errorf("Expected the table to be between the surrounding paragraphs, is at: %d (%d)",
+			table.Position, table.ParagraphCount)
+	}
+
+	if len(table.Rows) != 3 {
+		t.Fatalf("Expected to have 3 rows, has: %d", len(table.Rows))
+	}
+
+	header := table.Rows[0].Cells
+	if len(header) != 2 || header[0].Text != "Name" || header[1].Text != "Details" {
+		t.Errorf("Unexpected header row: %v", header)
+	} else if header[1].GridSpan != 2 {
+		t.Errorf("Expected the second header cell to span 2 columns, spans: %d", header[1].GridSpan)
+	}
+
+	cells := table.Rows[1].Cells
+	if len(cells) != 3 {
+		t.Fatalf("Expected to have 3 cells in the second row, has: %d", len(cells))
+	}
+
+	if cells[0].VerticalMerge != MergeRestart || table.Rows[2].Cells[0].VerticalMerge != MergeContinue {
+		t.Errorf("Expected the first column to be merged vertically")
+	}
+
+	if cells[1].Text != "First\nparagraphs" {
+		t.Errorf("Expected the cell text to be: %q, was: %q", "First\nparagraphs", cells[1].Text)
+	}
+
+	if len(cells[2].Tables) != 1 {
+		t.Fatalf("Expected to have a nested table, has: %d", len(cells[2].Tables))
+	}
+
+	nested := cells[2].Tables[0]
+	if len(nested.Rows) != 1 || len(nested.Rows[0].Cells) != 2 || nested.Rows[0].Cells[1].Text != "Inner 2" {
+		t.Errorf("Unexpected nested table: %v", nested)
+	}
+
+	if cells[2].Paragraphs[0] != "Outer" || len(cells[2].Paragraphs) != 2 {
+		t.Errorf("Expected the nested table's text not to be part of the cell's paragraphs: %q",
+			cells[2].Paragraphs)
+	}
+}
+
+func TestReadingDocxComments(t *testing.T) {
+	path := "../../test_data/comments.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	if len(doc.Comments) != 3 {
+		t.Fatalf("Expected to have 3 comments, has: %d", len(doc.Comments))
+	}
+
+	first := doc.Comments[0]
+	if first.Author != "Alice Reviewer" || first.Initials != "AR" || first.Text != "Should this be 45 days?" {
+		t.Errorf("Unexpected first comment: %v", first)
+	}
+
+	if first.Date.Year() != 2023 || first.Date.Month() != 3 || first.Date.Day() != 1 {
+		t.Errorf("Expected the first comment to be dated 2023-03-01, was: %s", first.Date)
+	}
+
+	if first.Anchor != "30 days" || !first.Resolved || first.ParentId != "" {
+		t.Errorf("Unexpected anchor or thread information of the first comment: %v", first)
+	}
+
+	second := doc.Comments[1]
+	anchor := " of the invoice.\nLate fees apply."
+	if second.Anchor != anchor || second.Text != "Please define\nlate fees." || second.Resolved {
+		t.Errorf("Unexpected second comment: %v", second)
+	}
+
+	reply := doc.Comments[2]
+	if reply.ParentId != first.Id || reply.Anchor != "" {
+		t.Errorf("Expected the third comment to be a reply to the first one: %v", reply)
+	}
+}
+
+func TestReadingDocxWithUnreadableComments(t *testing.T) {
+	path := "../../test_data/comments.docx"
+
+	doc, err := MakeDocxFromBytes(removingPart(t, path, "word/comments.xml"))
+	if err != nil {
+		t.Fatalf("Expected the dangling comments relationship to be skipped: %s", err.Error())
+	}
+
+	if len(doc.Comments) != 0 || !strings.Contains(doc.Text, "30 days") {
+		t.Errorf("Expected the text without comments, found: %v", doc.Comments)
+	}
+
+	doc, err = MakeDocxFromBytes(replacingPart(t, path, "word/commentsExtended.xml", "<broken"))
+	if err != nil {
+		t.Fatalf("Expected the broken extended comments to be skipped: %s", err.Error())
+	}
+
+	if len(doc.Comments) != 3 || doc.Comments[0].Resolved || doc.Comments[2].ParentId != "" {
+		t.Errorf("Expected the comments without their thread information: %v", doc.Comments)
+	}
+
+	if doc.Comments[0].Text != "Should this be 45 days?" {
+		t.Errorf("Unexpected first comment: %v", doc.Comments[0])
+	}
+}
+
+func TestReadingDocxRevisions(t *testing.T) {
+	path := "../../test_data/revisions.docx"
+	expected := map[RevisionMode][]string{
+		RevisionsAccepted: {
+			"The fee is forty euros.",
+			" Stays.",
+			"Intro. Moved sentence.",
+		},
+		RevisionsOriginal: {
+			"The fee is thirty euros.",
+			"Moved sentence. Stays.",
+			"Intro. ",
+		},
+		RevisionsAnnotated: {
+			"The fee is {+forty+}[-thirty-] euros.",
+			"[-Moved sentence.-] Stays.",
+			"Intro. {+Moved sentence.+}",
+		},
+	}
+
+	for mode, paragraphs := range expected {
+		doc, err := MakeDocx(path, WithRevisions(mode))
+
+		if err != nil {
+			t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+		}
+
+		if strings.Join(doc.Paragraphs, "\n") != strings.Join(paragraphs, "\n") {
+			t.Errorf("Expected the paragraphs in mode %d to be: %q, were: %q", mode, paragraphs, doc.Paragraphs)
+		}
+	}
+
+	doc, err := MakeDocx(path)
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	kinds := []RevisionKind{Insertion, Deletion, MoveFrom, MoveTo}
+	if len(doc.Revisions) != len(kinds) {
+		t.Fatalf("Expected to have %d revisions, has: %d", len(kinds), len(doc.Revisions))
+	}
+
+	for i, kind := range kinds {
+		if doc.Revisions[i].Kind != kind {
+			t.Errorf("Expected revision %d to be a %s, was: %s", i, kind, doc.Revisions[i].Kind)
+		}
+	}
+
+	deletion := doc.Revisions[1]
+	if deletion.Author != "Bob" || deletion.Text != "thirty" || deletion.Date.Day() != 5 {
+		t.Errorf("Unexpected deletion: %v", deletion)
+	}
+}
+
+func TestReadingDocxEndnotes(t *testing.T) {
+	path := "../../test_data/endnotes.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	expected := []Note{
+		{Id: "1", Text: "Gammel Estrup, 1998."},
+		{Id: "2", Text: "Statistics Denmark, 2021."},
+	}
+
+	if len(doc.Endnotes) != len(expected) {
+		t.Fatalf("Expected to have %d endnotes, has: %d", len(expected), len(doc.Endnotes))
+	}
+
+	for i, note := range expected {
+		if doc.Endnotes[i] != note {
+			t.Errorf("Expected endnote %d to be: %v, was: %v", i, note, doc.Endnotes[i])
+		}
+	}
+
+	if len(doc.Footnotes) != 1 || doc.Footnotes[0].Id != "1" || doc.Footnotes[0].Text != "See the census." {
+		t.Errorf("Expected to have a single footnote without the separators, has: %v", doc.Footnotes)
+	}
+}
+
+func TestReadingDocxLinks(t *testing.T) {
+	path := "../../test_data/links.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	url := "https://en.wikipedia.org/wiki/Aarhus"
+	expected := []Link{
+		{Text: "Aarhus", Target: url, Internal: false, Position: 4},
+		{Text: "the city", Target: url, Internal: false, Position: 15},
+		{Text: "history", Target: "history", Internal: true, Position: 37},
+	}
+
+	if len(doc.Links) != len(expected) {
+		t.Fatalf("Expected to have %d links, has: %d", len(expected), len(doc.Links))
+	}
+
+	for i, link := range expected {
+		if doc.Links[i] != link {
+			t.Errorf("Expected link %d to be: %v, was: %v", i, link, doc.Links[i])
+		}
+
+		if doc.Text[link.Position:link.Position+len(link.Text)] != link.Text {
+			t.Errorf("Expected link %d to point to its text in the document text", i)
+		}
+	}
+}
+
+func TestReadingDocxInlineLinks(t *testing.T) {
+	path := "../../test_data/links.docx"
+	doc, err := MakeDocx(path, WithInlineLinks())
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	text := "See Aarhus <https://en.wikipedia.org/wiki/Aarhus> and the city <https://en.wikipedia.org/wiki/Aarhus>.\n" +
+		"Jump to the history <#history> section."
+	if doc.Text != text {
+		t.Errorf("Expected the text to be: %q, was: %q", text, doc.Text)
+	}
+
+	for i, link := range doc.Links {
+		if doc.Text[link.Position:link.Position+len(link.Text)] != link.Text {
+			t.Errorf("Expected link %d to point to its text in the document text", i)
+		}
+	}
+}
+
+func TestReadingDocxSections(t *testing.T) {
+	path := "../../test_data/sections.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	if len(doc.Sections) != 2 {
+		t.Fatalf("Expected to have 2 sections, has: %d", len(doc.Sections))
+	}
+
+	first := doc.Sections[0]
+	if first.Headers != (HeaderFooter{Default: "Report", First: "Confidential draft"}) {
+		t.Errorf("Unexpected headers of the first section: %v", first.Headers)
+	}
+
+	if first.Footers.Default != "Page footer" || first.Orientation != Portrait || first.PageWidth != 11906 {
+		t.Errorf("Unexpected first section: %v", first)
+	}
+
+	if doc.Paragraphs[first.LastParagraph] != "End of part one" {
+		t.Errorf("Expected the first section to end with its second paragraph, ends with: %d", first.LastParagraph)
+	}
+
+	second := doc.Sections[1]
+	expected := HeaderFooter{Default: "Appendix", First: "Confidential draft"}
+	if second.Headers != expected || second.Footers.Default != "Page footer" {
+		t.Errorf("Expected the second section to inherit the missing headers and footers: %v", second)
+	}
+
+	if second.Orientation != Landscape || second.LastParagraph != len(doc.Paragraphs)-1 {
+		t.Errorf("Unexpected second section: %v", second)
+	}
+
+	headers := []string{"Report", "Confidential draft", "Appendix"}
+	if strings.Join(doc.Headers, "|") != strings.Join(headers, "|") {
+		t.Errorf("Expected the referenced headers to be: %v, were: %v", headers, doc.Headers)
+	}
+}
+
+func TestReadingDocxSectionsWithBrokenHeader(t *testing.T) {
+	data := replacingPart(t, "../../test_data/sections.docx", "word/header3.xml", "<w:hdr><w:p>")
+
+	doc, err := MakeDocxFromBytes(data)
+	if err != nil {
+		t.Fatalf("Expected the broken header to be skipped: %s", err.Error())
+	}
+
+	if len(doc.Sections) != 2 {
+		t.Fatalf("Expected to have 2 sections, has: %d", len(doc.Sections))
+	}
+
+	if first := doc.Sections[0]; first.Headers.Default != "Report" || first.PageWidth != 11906 {
+		t.Errorf("Expected the first section to be intact: %v", first)
+	}
+
+	if second := doc.Sections[1]; second.Headers.Default != "" || second.Orientation != Landscape {
+		t.Errorf("Expected the second section without its broken header: %v", second)
+	}
+
+	headers := []string{"Report", "Confidential draft"}
+	if strings.Join(doc.Headers, "|") != strings.Join(headers, "|") {
+		t.Errorf("Expected the readable headers to be: %v, were: %v", headers, doc.Headers)
+	}
+}
@@ -0,0 +1,136 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadingEncryptedDocx(t *testing.T) {
+	expected, err := MakeDocx("../../test_data/example.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the unencrypted document: %s", err.Error())
+	}
+
+	doc, err := MakeDocx("../../test_data/encrypted_agile.docx", WithPassword("secret"))
+	if err != nil {
+		t.Fatalf("Failed to decrypt the document: %s", err.Error())
+	}
+
+	if doc.Text != expected.Text {
+		t.Errorf("Expected the text of the decrypted document to be:\n%s\nwas:\n%s", expected.Text, doc.Text)
+	}
+}
+
+func TestReadingEncryptedXlsx(t *testing.T) {
+	expected, err := MakeXlsx("../../test_data/example.xlsx")
+	if err != nil {
+		t.Fatalf("Failed to read the unencrypted document: %s", err.Error())
+	}
+
+	file, err := os.Open("../../test_data/encrypted_standard.xlsx")
+	if err != nil {
+		t.Fatalf("Failed to open the document: %s", err.Error())
+	}
+	defer file.Close()
+
+	doc, err := OpenStream(file, WithPassword("secret"))
+	if err != nil {
+		t.Fatalf("Failed to decrypt the document: %s", err.Error())
+	}
+
+	xlsx, ok := doc.(*Xlsx)
+	if !ok {
+		t.Fatalf("Expected an *Xlsx, got: %T", doc)
+	}
+
+	if !reflect.DeepEqual(xlsx.Text, expected.Text) {
+		t.Errorf("Expected the text of the decrypted document to be: %v, was: %v", expected.Text, xlsx.Text)
+	}
+}
+
+func TestEncryptedWithoutPassword(t *testing.T) {
+	for _, path := range []string{"../../test_data/encrypted_agile.docx", "../../test_data/encrypted_standard.xlsx"} {
+		if _, err := Open(path); !errors.Is(err, ErrEncrypted) {
+			t.Errorf("Expected an ErrEncrypted error for %s, got: %v", path, err)
+		}
+	}
+}
+
+func TestEncryptedWithWrongPassword(t *testing.T) {
+	for _, path := range []string{"../../test_data/encrypted_agile.docx", "../../test_data/encrypted_standard.xlsx"} {
+		if _, err := Open(path, WithPassword("wrong")); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("Expected an ErrWrongPassword error for %s, got: %v", path, err)
+		}
+	}
+}
+
+func TestPasswordOfUnencryptedDocument(t *testing.T) {
+	if _, err := MakeDocx("../../test_data/example.docx", WithPassword("secret")); err != nil {
+		t.Errorf("Expected the password to be ignored: %s", err.Error())
+	}
+}
+
+func TestEncryptedWithInvalidKeySize(t *testing.T) {
+	data, err := os.ReadFile("../../test_data/encrypted_standard.xlsx")
+	if err != nil {
+		t.Fatalf("Failed to read the document: %s", err.Error())
+	}
+
+	// AES-128 with SHA-1 and a 128 bit key turned into a 512 bit key.
+	header := []byte{0x0e, 0x66, 0, 0, 0x04, 0x80, 0, 0, 0x80, 0, 0, 0}
+	if !bytes.Contains(data, header) {
+		t.Fatalf("The encryption header of the document not found")
+	}
+	data = bytes.Replace(data, header, []byte{0x0e, 0x66, 0, 0, 0x04, 0x80, 0, 0, 0, 0x02, 0, 0}, 1)
+
+	if _, err := MakeXlsxFromBytes(data, WithPassword("secret")); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected an ErrEncrypted error, got: %v", err)
+	}
+}
+
+func TestEncryptedWithInvalidStreamSize(t *testing.T) {
+	data, err := os.ReadFile("../../test_data/encrypted_standard.xlsx")
+	if err != nil {
+		t.Fatalf("Failed to read the document: %s", err.Error())
+	}
+
+	// The size of the EncryptionInfo stream is set past the end of the file.
+	name := []byte("E\x00n\x00c\x00r\x00y\x00p\x00t\x00i\x00o\x00n\x00I\x00n\x00f\x00o\x00")
+	entry := bytes.Index(data, name)
+	if entry < 0 {
+		t.Fatalf("The directory entry of the EncryptionInfo stream not found")
+	}
+	copy(data[entry+120:], []byte{0xff, 0xff, 0xff, 0x7f})
+
+	if _, err := MakeXlsxFromBytes(data, WithPassword("secret")); err == nil {
+		t.Errorf("Expected an error for the invalid stream size")
+	}
+}
+
+func TestEncryptedWithInvalidAgileParameters(t *testing.T) {
+	data, err := os.ReadFile("../../test_data/encrypted_agile.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the document: %s", err.Error())
+	}
+
+	for _, c := range []struct {
+		attribute string
+		invalid   string
+	}{
+		{`keyBits="256"`, `keyBits="-64"`},
+		{`blockSize="16"`, `blockSize="-1"`},
+	} {
+		if !bytes.Contains(data, []byte(c.attribute)) {
+			t.Fatalf("The attribute %s not found in the encryption descriptor", c.attribute)
+		}
+
+		corrupted := bytes.Replace(data, []byte(c.attribute), []byte(c.invalid), -1)
+
+		if _, err := MakeDocxFromBytes(corrupted, WithPassword("secret")); !errors.Is(err, ErrEncrypted) {
+			t.Errorf("Expected an ErrEncrypted error for %s, got: %v", c.invalid, err)
+		}
+	}
+}
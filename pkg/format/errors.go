@@ -0,0 +1,59 @@
+package format
+
+import (
+	"github.com/nagygr/ooxml2txt/internal/archive"
+)
+
+// The kinds of errors the constructors report, they can be told apart with
+// errors.Is (the underlying causes, e.g. the errors of archive/zip or
+// encoding/xml, are wrapped and can be reached with errors.As):
+//
+//	doc, err := format.Open(path)
+//	if errors.Is(err, format.ErrEncrypted) {
+//		doc, err = format.Open(path, format.WithPassword(password))
+//	}
+var (
+	// ErrNotZip is reported for inputs that are not zip archives.
+	ErrNotZip = archive.ErrNotZip
+	// ErrNotOoxml is reported for zip archives that are not OOXML packages
+	// (and for the legacy binary Office formats).
+	ErrNotOoxml = archive.ErrNotOoxml
+	// ErrUnsupported is reported by Open and its variants for OOXML packages
+	// that are neither text documents nor presentations nor spreadsheets.
+	ErrUnsupported = archive.ErrUnsupported
+	// ErrMissingPart is reported for packages that lack a part needed to
+	// process them (e.g. word/document.xml).
+	ErrMissingPart = archive.ErrMissingPart
+	// ErrEncrypted is reported for password protected documents opened
+	// without a password (see WithPassword) and for the ones using an
+	// encryption that is not supported.
+	ErrEncrypted = archive.ErrEncrypted
+	// ErrWrongPassword is reported for encrypted documents opened with a
+	// wrong password.
+	ErrWrongPassword = archive.ErrWrongPassword
+	// ErrMalformedXml is reported for parts that are not well-formed XML, the
+	// details are available through XmlError.
+	ErrMalformedXml = archive.ErrMalformedXml
+)
+
+// XmlError is the error of a part that is not well-formed XML. Part is the
+// name of the part, Offset is the byte offset within the part where the
+// problem was found and Err is the error of the XML parser. It can be reached
+// with errors.As:
+//
+//	var xmlErr *format.XmlError
+//	if errors.As(err, &xmlErr) {
+//		fmt.Println(xmlErr.Part, xmlErr.Offset)
+//	}
+type XmlError = archive.XmlError
+
+// HttpError is the error of the *FromUrl constructors when the server answers
+// with a status code other than 2xx. Url is the URL of the document,
+// StatusCode and Status are the status code and the status line of the
+// response. It can be reached with errors.As:
+//
+//	var httpErr *format.HttpError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+//		fmt.Println("No such document:", httpErr.Url)
+//	}
+type HttpError = archive.HttpError
@@ -0,0 +1,119 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotZipError(t *testing.T) {
+	_, err := MakeDocxFromBytes([]byte("This is a plain text file."))
+
+	if !errors.Is(err, ErrNotZip) {
+		t.Errorf("Expected an ErrNotZip error, got: %v", err)
+	}
+
+	if !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("Expected the error of archive/zip to be wrapped, got: %v", err)
+	}
+}
+
+func TestEncryptedError(t *testing.T) {
+	ole := append([]byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}, make([]byte, 1024)...)
+	encrypted := append(ole, []byte("E\x00n\x00c\x00r\x00y\x00p\x00t\x00e\x00d\x00P\x00a\x00c\x00k\x00a\x00g\x00e\x00")...)
+
+	path := filepath.Join(t.TempDir(), "encrypted.docx")
+	if err := ioutil.WriteFile(path, encrypted, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %s", path, err.Error())
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected an ErrEncrypted error, got: %v", err)
+	}
+
+	if _, err := OpenBytes(encrypted); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected an ErrEncrypted error, got: %v", err)
+	}
+
+	if _, err := OpenBytes(ole); !errors.Is(err, ErrNotOoxml) {
+		t.Errorf("Expected an ErrNotOoxml error for a legacy binary document, got: %v", err)
+	}
+}
+
+func TestNotOoxmlError(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	w, _ := writer.Create("readme.txt")
+	w.Write([]byte("Not a document"))
+	writer.Close()
+
+	if _, err := OpenBytes(archive.Bytes()); !errors.Is(err, ErrNotOoxml) {
+		t.Errorf("Expected an ErrNotOoxml error from Open, got: %v", err)
+	}
+
+	if _, err := MakeDocxFromBytes(archive.Bytes()); !errors.Is(err, ErrNotOoxml) {
+		t.Errorf("Expected an ErrNotOoxml error from MakeDocx, got: %v", err)
+	}
+}
+
+func TestUnsupportedError(t *testing.T) {
+	contentTypes := `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.ms-visio.drawing.main+xml"/>
+</Types>`
+	data := replacingPart(t, "../../test_data/example.docx", "[Content_Types].xml", contentTypes)
+
+	if _, err := OpenBytes(data); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected an ErrUnsupported error, got: %v", err)
+	}
+}
+
+func TestMissingPartError(t *testing.T) {
+	_, err := MakeDocx("../../test_data/broken_missing_document_xml.docx")
+
+	if !errors.Is(err, ErrMissingPart) {
+		t.Errorf("Expected an ErrMissingPart error, got: %v", err)
+	}
+}
+
+func TestMalformedXmlError(t *testing.T) {
+	body := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:body><w:p><w:r><w:t>Unclosed</w:r></w:p></w:body></w:document>`
+	data := replacingPart(t, "../../test_data/example.docx", "word/document.xml", body)
+
+	_, err := MakeDocxFromBytes(data)
+	if !errors.Is(err, ErrMalformedXml) {
+		t.Fatalf("Expected an ErrMalformedXml error, got: %v", err)
+	}
+
+	var xmlErr *XmlError
+	if !errors.As(err, &xmlErr) {
+		t.Fatalf("Expected an *XmlError, got: %T", err)
+	}
+
+	offset := int64(strings.Index(body, "</w:r>") + len("</w:r>"))
+	if xmlErr.Part != "word/document.xml" || xmlErr.Offset != offset {
+		t.Errorf("Expected the error in word/document.xml at %d, got: %s at %d", offset, xmlErr.Part, xmlErr.Offset)
+	}
+
+	var syntaxErr *xml.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected the error of encoding/xml to be wrapped, got: %v", xmlErr.Err)
+	}
+}
+
+func TestMalformedSlideError(t *testing.T) {
+	data := replacingPart(t, "../../test_data/example.pptx", "ppt/slides/slide1.xml", "<p:sld><p:cSld>")
+
+	var xmlErr *XmlError
+	if _, err := MakePptxFromBytes(data); !errors.As(err, &xmlErr) || xmlErr.Part != "ppt/slides/slide1.xml" {
+		t.Errorf("Expected an *XmlError in ppt/slides/slide1.xml, got: %v", err)
+	}
+}
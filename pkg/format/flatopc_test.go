@@ -0,0 +1,66 @@
+package format
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadingFlatOpcDocx(t *testing.T) {
+	expected, _ := MakeDocx("../../test_data/example.docx")
+
+	doc, err := MakeDocx("../../test_data/flat_document.xml")
+	if err != nil {
+		t.Fatalf("Failed to read the Flat OPC document: %s", err.Error())
+	}
+
+	if doc.Text != expected.Text {
+		t.Errorf("Expected the text of the Flat OPC document to be:\n%s\nwas:\n%s", expected.Text, doc.Text)
+	}
+
+	if !reflect.DeepEqual(doc.Properties, expected.Properties) {
+		t.Errorf("Expected the properties of the Flat OPC document to be: %v, was: %v", expected.Properties, doc.Properties)
+	}
+}
+
+func TestOpeningFlatOpcPresentation(t *testing.T) {
+	expected, _ := MakePptx("../../test_data/example.pptx")
+
+	file, err := os.Open("../../test_data/flat_presentation.xml")
+	if err != nil {
+		t.Fatalf("Failed to open the presentation: %s", err.Error())
+	}
+	defer file.Close()
+
+	doc, err := OpenStream(file)
+	if err != nil {
+		t.Fatalf("Failed to read the Flat OPC presentation: %s", err.Error())
+	}
+
+	pptx, ok := doc.(*Pptx)
+	if !ok {
+		t.Fatalf("Expected a *Pptx, got: %T", doc)
+	}
+
+	if !reflect.DeepEqual(pptx.Text, expected.Text) {
+		t.Errorf("Expected the slides of the Flat OPC presentation to be: %v, was: %v", expected.Text, pptx.Text)
+	}
+}
+
+func TestFlatOpcErrors(t *testing.T) {
+	if _, err := OpenBytes([]byte(`<?xml version="1.0"?><html><body/></html>`)); !errors.Is(err, ErrNotOoxml) {
+		t.Errorf("Expected an ErrNotOoxml error for an XML document, got: %v", err)
+	}
+
+	content, _ := ioutil.ReadFile("../../test_data/flat_document.xml")
+	if _, err := OpenBytes(content[:len(content)/2]); !errors.Is(err, ErrMalformedXml) {
+		t.Errorf("Expected an ErrMalformedXml error for a truncated document, got: %v", err)
+	}
+
+	var limitErr *LimitError
+	if _, err := OpenBytes(content, WithLimits(Limits{MaxPartSize: 1024})); !errors.As(err, &limitErr) {
+		t.Errorf("Expected the limits to apply to Flat OPC documents, got: %v", err)
+	}
+}
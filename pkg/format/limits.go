@@ -0,0 +1,36 @@
+package format
+
+import (
+	"errors"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+)
+
+// Limits restricts the resources processing a document may use, which is
+// needed for documents from untrusted sources (e.g. zip bombs). The fields
+// are: MaxPartSize (the maximum decompressed size of a part in bytes),
+// MaxTotalSize (the maximum number of decompressed bytes read from the
+// document), MaxCompressionRatio (the maximum ratio of the decompressed and
+// the compressed size of a part), MaxEntries (the maximum number of files in
+// the package), MaxXmlDepth (the maximum nesting depth of the XML elements of
+// a part) and MaxXmlTokens (the maximum number of XML tokens of a part). Zero
+// values mean no limit. Limits can be set with the WithLimits option.
+type Limits = archive.Limits
+
+// LimitError is the error reported when a document exceeds one of its Limits.
+// Limit is the name of the exceeded field of Limits, Value is its value and
+// Part is the name of the part exceeding it (empty for MaxEntries and
+// MaxTotalSize). It can be detected with errors.As:
+//
+//	var limitErr *format.LimitError
+//	if errors.As(err, &limitErr) {
+//		fmt.Println("Rejected:", limitErr.Limit)
+//	}
+type LimitError = archive.LimitError
+
+// isLimitError tells whether the error is (or wraps) a *LimitError. The
+// errors of the optional parts of the documents are ignored unless they are
+// limit errors.
+func isLimitError(err error) bool {
+	var limitErr *LimitError
+	return errors.As(err, &limitErr)
+}
@@ -0,0 +1,167 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// replacingPart returns the document with one of its parts replaced.
+func replacingPart(t *testing.T, path string, name string, data string) []byte {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", path, err.Error())
+	}
+
+	source, _ := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+	var result bytes.Buffer
+	writer := zip.NewWriter(&result)
+
+	for _, f := range source.File {
+		w, _ := writer.Create(f.Name)
+
+		if f.Name == name {
+			w.Write([]byte(data))
+			continue
+		}
+
+		r, _ := f.Open()
+		io.Copy(w, r)
+		r.Close()
+	}
+
+	writer.Close()
+	return result.Bytes()
+}
+
+// removingPart returns the document without one of its parts.
+func removingPart(t *testing.T, path string, name string) []byte {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", path, err.Error())
+	}
+
+	source, _ := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+	var result bytes.Buffer
+	writer := zip.NewWriter(&result)
+
+	for _, f := range source.File {
+		if f.Name == name {
+			continue
+		}
+
+		w, _ := writer.Create(f.Name)
+		r, _ := f.Open()
+		io.Copy(w, r)
+		r.Close()
+	}
+
+	writer.Close()
+	return result.Bytes()
+}
+
+// renamingPart returns the document with one of its parts renamed.
+func renamingPart(t *testing.T, path string, name string, newName string) []byte {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", path, err.Error())
+	}
+
+	source, _ := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+	var result bytes.Buffer
+	writer := zip.NewWriter(&result)
+
+	for _, f := range source.File {
+		fileName := f.Name
+		if fileName == name {
+			fileName = newName
+		}
+
+		w, _ := writer.Create(fileName)
+		r, _ := f.Open()
+		io.Copy(w, r)
+		r.Close()
+	}
+
+	writer.Close()
+	return result.Bytes()
+}
+
+func expectLimitError(t *testing.T, data []byte, limits Limits, limit string) {
+	_, err := MakeDocxFromBytes(data, WithLimits(limits))
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Expected a *LimitError for %s, got: %v", limit, err)
+		return
+	}
+
+	if limitErr.Limit != limit {
+		t.Errorf("Expected the %s limit to be exceeded, was: %s", limit, limitErr.Limit)
+	}
+}
+
+func TestDocumentsWithinLimits(t *testing.T) {
+	limits := Limits{
+		MaxPartSize:         1 << 20,
+		MaxTotalSize:        10 << 20,
+		MaxCompressionRatio: 100,
+		MaxEntries:          100,
+		MaxXmlDepth:         64,
+		MaxXmlTokens:        100000,
+	}
+
+	for _, path := range []string{
+		"../../test_data/example.docx", "../../test_data/example.pptx", "../../test_data/example.xlsx",
+	} {
+		if _, err := Open(path, WithLimits(limits)); err != nil {
+			t.Errorf("Expected %s to be within the limits: %s", path, err.Error())
+		}
+	}
+}
+
+func TestExceedingArchiveLimits(t *testing.T) {
+	example, _ := ioutil.ReadFile("../../test_data/example.docx")
+
+	expectLimitError(t, example, Limits{MaxEntries: 3}, "MaxEntries")
+	expectLimitError(t, example, Limits{MaxPartSize: 1024}, "MaxPartSize")
+	expectLimitError(t, example, Limits{MaxTotalSize: 4096}, "MaxTotalSize")
+
+	body := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		strings.Repeat(" ", 8<<20) + `</w:body></w:document>`
+	bomb := replacingPart(t, "../../test_data/example.docx", "word/document.xml", body)
+
+	expectLimitError(t, bomb, Limits{MaxCompressionRatio: 100}, "MaxCompressionRatio")
+}
+
+func TestExceedingXmlLimits(t *testing.T) {
+	body := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		strings.Repeat("<w:p>", 100) + strings.Repeat("</w:p>", 100) + `</w:body></w:document>`
+	deep := replacingPart(t, "../../test_data/example.docx", "word/document.xml", body)
+
+	expectLimitError(t, deep, Limits{MaxXmlDepth: 50}, "MaxXmlDepth")
+	expectLimitError(t, deep, Limits{MaxXmlTokens: 100}, "MaxXmlTokens")
+
+	if _, err := MakeDocxFromBytes(deep, WithLimits(Limits{MaxXmlDepth: 200})); err != nil {
+		t.Errorf("Expected the document to be within the limits: %s", err.Error())
+	}
+}
+
+func TestExceedingLimitsInOptionalParts(t *testing.T) {
+	header := `<w:hdr xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:p><w:r><w:t>` +
+		strings.Repeat("x", 1<<20) + `</w:t></w:r></w:p></w:hdr>`
+	expectLimitError(t, replacingPart(t, "../../test_data/example.docx", "word/header1.xml", header),
+		Limits{MaxPartSize: 64 << 10}, "MaxPartSize")
+
+	core := `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties">` +
+		strings.Repeat("<a>", 100) + strings.Repeat("</a>", 100) + `</cp:coreProperties>`
+	expectLimitError(t, replacingPart(t, "../../test_data/example.docx", "docProps/core.xml", core),
+		Limits{MaxXmlDepth: 50}, "MaxXmlDepth")
+}
@@ -0,0 +1,141 @@
+package format
+
+import (
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"net/http"
+)
+
+// Option changes the way a document is processed. Options can be passed to
+// any of the constructors (Open, MakeDocx, MakePptx, etc.), the ones that
+// don't apply to the given format are ignored.
+type Option func(*options)
+
+// defaultSpoolThreshold is the size up to which the documents read from
+// streams are kept in memory.
+const defaultSpoolThreshold = 16 << 20
+
+// options holds the settings the Option values can change.
+type options struct {
+	revisions      RevisionMode
+	inlineLinks    bool
+	spoolThreshold int64
+	httpClient     *http.Client
+	httpHeader     http.Header
+	maxDownload    int64
+	rangeRequests  bool
+	limits         Limits
+	password       string
+}
+
+// makeOptions applies the given options to the default settings.
+func makeOptions(opts []Option) options {
+	settings := options{
+		revisions:      RevisionsAccepted,
+		spoolThreshold: defaultSpoolThreshold,
+		httpHeader:     http.Header{},
+	}
+
+	for _, o := range opts {
+		o(&settings)
+	}
+
+	return settings
+}
+
+// WithRevisions sets how the tracked changes of docx documents are reflected
+// in the extracted text. The default is RevisionsAccepted.
+func WithRevisions(mode RevisionMode) Option {
+	return func(o *options) {
+		o.revisions = mode
+	}
+}
+
+// WithInlineLinks makes the text of docx documents contain the targets of the
+// hyperlinks right after their text in the form: "text <url>" (links to
+// bookmarks within the document are rendered as "text <#bookmark>").
+func WithInlineLinks() Option {
+	return func(o *options) {
+		o.inlineLinks = true
+	}
+}
+
+// WithSpoolThreshold sets the size (in bytes) up to which the documents read by
+// the *FromStream and *FromUrl constructors are kept in memory. Larger
+// documents are written to a temporary file that is removed once the document
+// is processed. The default is 16 MiB.
+func WithSpoolThreshold(threshold int64) Option {
+	return func(o *options) {
+		o.spoolThreshold = threshold
+	}
+}
+
+// WithHttpClient sets the client the *FromUrl constructors use to download
+// the documents (e.g. to set a timeout or a proxy). The default is
+// http.DefaultClient.
+func WithHttpClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithHttpHeader adds a header to the requests the *FromUrl constructors send
+// (e.g. an Authorization header).
+func WithHttpHeader(name string, value string) Option {
+	return func(o *options) {
+		o.httpHeader.Add(name, value)
+	}
+}
+
+// WithMaxDownloadSize limits the size (in bytes) of the documents the
+// *FromUrl constructors download. Larger documents are reported as errors.
+// The default is no limit.
+func WithMaxDownloadSize(size int64) Option {
+	return func(o *options) {
+		o.maxDownload = size
+	}
+}
+
+// WithRangeRequests makes the *FromUrl constructors download only the parts of
+// the document that are actually read (using HTTP Range requests) instead of
+// the whole file, which pays off for large documents full of media. If the
+// server doesn't support Range requests, the whole file is downloaded. With
+// this option WithMaxDownloadSize limits the number of bytes downloaded rather
+// than the size of the document.
+func WithRangeRequests() Option {
+	return func(o *options) {
+		o.rangeRequests = true
+	}
+}
+
+// urlOptions returns the settings of the downloads.
+func (o options) urlOptions() archive.UrlOptions {
+	return archive.UrlOptions{
+		Client:        o.httpClient,
+		Header:        o.httpHeader,
+		MaxSize:       o.maxDownload,
+		MemoryLimit:   o.spoolThreshold,
+		RangeRequests: o.rangeRequests,
+		Password:      o.password,
+	}
+}
+
+// WithLimits sets the limits that protect against documents that would use
+// excessive resources (e.g. zip bombs). Documents exceeding them are reported
+// with a *LimitError. By default there are no limits.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithPassword sets the password of encrypted (password protected) documents.
+// Documents encrypted with the agile or the standard encryption of Office are
+// decrypted in memory before they are processed. Encrypted documents opened
+// without a password are reported with an ErrEncrypted error, the ones with a
+// wrong password with an ErrWrongPassword error. The option has no effect on
+// documents that are not encrypted.
+func WithPassword(password string) Option {
+	return func(o *options) {
+		o.password = password
+	}
+}
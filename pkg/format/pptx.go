@@ -1,57 +1,372 @@
 package format
 
 import (
+	"context"
+	"encoding/xml"
+	"fmt"
 	"github.com/nagygr/ooxml2txt/internal/archive"
 	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+const (
+	pptxMainPart = "ppt/presentation.xml"
+
+	slideRelationshipType      = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+	notesSlideRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
 )
 
 // Pptx handles pptx documents. The Text member is a list of strings where each
-// element corresponds to a slide in the presentation.
+// element corresponds to a slide in the presentation. Slides contains the
+// same texts together with the number of the slide and the name of the part
+// holding it. Notes contains the speaker notes of the slides (an empty string
+// for slides without notes). All three lists follow the order of the slides in
+// the presentation. Properties contains the metadata of the presentation.
+// Macro-enabled presentations, templates and slide shows (pptm, potx, potm,
+// ppsx, ppsm) are handled the same way, their Variant method tells which one
+// the document is.
 type Pptx struct {
-	zipReader archive.ZipData
-	Text      []string
+	Text       []string
+	Notes      []string
+	Slides     []Slide
+	Properties Properties
+
+	variant    Variant
+	vbaProject bool
+}
+
+// Slide is a slide of a presentation. Number is the 1-based position of the
+// slide in the presentation and Part is the name of the part holding it
+// inside the package (e.g. ppt/slides/slide1.xml). Notes contains the text of
+// the speaker notes without the slide number placeholder.
+type Slide struct {
+	Number int
+	Part   string
+	Text   string
+	Notes  string
 }
 
 // MakePptx creates a Pptx from the path to a presentation. The
 // returned instance contains the valid contents of the document if there was
 // no error while processing it (which is then reported in the returned error
 // value).
-func MakePptx(path string) (*Pptx, error) {
-	reader, err := archive.MakeZipFile(path)
+func MakePptx(path string, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFile(path, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makePptxFromReader(reader)
+	return makePptxFromReader(reader, nil, settings)
 }
 
 // MakePptxFromUrl creates a Pptx from an URL to a presentation. The returned
 // instance contains the valid contents of the document if there was no error
 // while processing it (which is then reported in the returned error value).
-func MakePptxFromUrl(url string) (*Pptx, error) {
-	reader, err := archive.MakeZipFileFromUrl(url)
+func MakePptxFromUrl(url string, opts ...Option) (*Pptx, error) {
+	return MakePptxFromUrlContext(context.Background(), url, opts...)
+}
+
+// MakePptxFromUrlContext creates a Pptx from an URL to a presentation. The
+// download is bound to the given context and can be configured with the
+// WithHttpClient, WithHttpHeader and WithMaxDownloadSize options. Responses
+// with a status code other than 2xx are reported as errors.
+func MakePptxFromUrlContext(ctx context.Context, url string, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromUrlContext(ctx, url, settings.urlOptions())
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makePptxFromReader(reader, nil, settings)
+}
+
+// MakePptxFromReader creates a Pptx from a presentation held by the given
+// reader (size is the length of the document in bytes). The reader is only
+// used during the call, the document is fully read by the time it returns.
+func MakePptxFromReader(readerAt io.ReaderAt, size int64, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromReaderAt(readerAt, size, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makePptxFromReader(reader)
+	return makePptxFromReader(reader, nil, settings)
 }
 
-func makePptxFromReader(reader archive.ZipData) (*Pptx, error) {
-	slideXmls, err := ReadXmls(reader, "ppt/slides/slide")
-	var slideTexts []string
+// MakePptxFromBytes creates a Pptx from a presentation held in memory.
+func MakePptxFromBytes(data []byte, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromBytes(data, settings.password)
 
-	if err == nil {
-		slideTexts, err = TextListFromXmls(slideXmls)
+	if err != nil {
+		return nil, err
 	}
+	defer reader.Close()
+
+	return makePptxFromReader(reader, nil, settings)
+}
+
+// MakePptxFromFS creates a Pptx from the presentation called name in the given
+// file system (e.g. an embed.FS or the result of os.DirFS).
+func MakePptxFromFS(fsys fs.FS, name string, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromFS(fsys, name, settings.password)
 
 	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makePptxFromReader(reader, nil, settings)
+}
+
+// MakePptxFromStream creates a Pptx from a presentation read from the given
+// reader (e.g. an HTTP request body). Only documents smaller than the spool
+// threshold (see WithSpoolThreshold) are held in memory, larger ones are
+// buffered in a temporary file.
+func MakePptxFromStream(stream io.Reader, opts ...Option) (*Pptx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromStream(stream, settings.spoolThreshold, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makePptxFromReader(reader, nil, settings)
+}
+
+// Kind returns KindPptx.
+func (p *Pptx) Kind() Kind {
+	return KindPptx
+}
+
+// Variant returns the variant of the presentation (VariantPptx, VariantPptm,
+// VariantPotx, VariantPotm, VariantPpsx or VariantPpsm).
+func (p *Pptx) Variant() Variant {
+	return p.variant
+}
+
+// HasVbaProject tells whether the presentation carries a VBA project.
+func (p *Pptx) HasVbaProject() bool {
+	return p.vbaProject
+}
+
+// makePptxFromReader processes the archive with the given settings. If pkg is
+// nil, the model of the package is read from the archive. The main part is
+// looked up in the package (ppt/presentation.xml is used for packages that
+// don't declare it).
+func makePptxFromReader(reader archive.ZipData, pkg *opc.Package, settings options) (*Pptx, error) {
+	if pkg == nil {
+		var err error
+		if pkg, err = readPackage(reader, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	mainPart := mainPartOrDefault(pkg, pptxMainPart)
+
+	slideParts, err := readSlideParts(reader, pkg, mainPart)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		slides     = []Slide{}
 		slideTexts = []string{}
+		notes      = []string{}
+	)
+
+	for n, part := range slideParts {
+		slideXml, err := ReadXml(reader, part)
+		if err != nil {
+			return nil, err
+		}
+
+		textList, err := TextListFromXml(slideXml)
+		if err != nil {
+			return nil, InPart(err, part)
+		}
+
+		slideNotes, err := readSlideNotes(reader, pkg, part)
+
+		if isLimitError(err) {
+			return nil, err
+		} else if err != nil {
+			slideNotes = ""
+		}
+
+		text := strings.Join(textList, " ")
+		slides = append(slides, Slide{Number: n + 1, Part: part, Text: text, Notes: slideNotes})
+		slideTexts = append(slideTexts, text)
+		notes = append(notes, slideNotes)
+	}
+
+	properties, err := readProperties(reader, pkg)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Pptx{
-		zipReader: reader,
-		Text:      slideTexts}, nil
+		Text:       slideTexts,
+		Notes:      notes,
+		Slides:     slides,
+		Properties: properties,
+		variant:    readVariant(pkg, mainPart, VariantPptx),
+		vbaProject: hasVbaProject(pkg, mainPart)}, nil
+}
+
+// readSlideNotes returns the text of the notes slide belonging to the given
+// slide or an empty string if the slide has no notes. The error is set if the
+// notes slide cannot be read.
+func readSlideNotes(reader archive.ZipData, pkg *opc.Package, slidePart string) (string, error) {
+	notesRelationships := pkg.RelationshipsOfType(slidePart, notesSlideRelationshipType)
+	if len(notesRelationships) == 0 || notesRelationships[0].External {
+		return "", nil
+	}
+
+	notesPart := notesRelationships[0].TargetPart()
+
+	notesXml, err := ReadXml(reader, notesPart)
+	if err != nil {
+		return "", err
+	}
+
+	textList, err := notesTextListFromXml(notesXml)
+	if err != nil {
+		return "", InPart(err, notesPart)
+	}
+
+	return strings.Join(textList, " "), nil
+}
+
+// readSlideParts returns the names of the slide parts in the order given by
+// the slide list of the presentation.
+func readSlideParts(reader archive.ZipData, pkg *opc.Package, mainPart string) ([]string, error) {
+	presentationXml, err := ReadXml(reader, mainPart)
+	if err != nil {
+		return nil, err
+	}
+
+	slideIds, err := slideIdsFromXml(presentationXml)
+	if err != nil {
+		return nil, InPart(err, mainPart)
+	}
+
+	var parts []string
+
+	for _, id := range slideIds {
+		r, found := pkg.Relationship(mainPart, id)
+
+		if !found || r.Type != slideRelationshipType || r.External {
+			return nil, &archive.Error{
+				Kind:    ErrMissingPart,
+				Message: fmt.Sprintf("The slide with relationship %s not found", id),
+			}
+		}
+
+		parts = append(parts, r.TargetPart())
+	}
+
+	return parts, nil
+}
+
+// slideIdsFromXml returns the relationship ids of the slide list of
+// presentation.xml in order.
+func slideIdsFromXml(presentationXml string) (ids []string, err error) {
+	var (
+		reader           = strings.NewReader(presentationXml)
+		decoder          = xml.NewDecoder(reader)
+		inSlideList bool = false
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "sldIdLst" {
+				inSlideList = true
+			} else if t.Name.Local == "sldId" && inSlideList {
+				ids = append(ids, RelationshipIdAttr(t))
+			}
+		case xml.EndElement:
+			if t.Name.Local == "sldIdLst" {
+				inSlideList = false
+			}
+		default:
+		}
+	}
+
+	return
+}
+
+// notesTextListFromXml returns the text fragments of a notes slide. The shapes
+// that are slide number placeholders are skipped as they only contain the
+// number of the slide.
+func notesTextListFromXml(notesXml string) (textList []string, err error) {
+	var (
+		reader        = strings.NewReader(notesXml)
+		decoder       = xml.NewDecoder(reader)
+		shapeText     []string
+		inText        bool = false
+		isSlideNumber bool = false
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if inText {
+				shapeText = append(shapeText, string(t))
+			}
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sp":
+				shapeText = nil
+				isSlideNumber = false
+			case "ph":
+				isSlideNumber = AttrValue(t, "type") == "sldNum"
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "sp":
+				if !isSlideNumber {
+					textList = append(textList, shapeText...)
+				}
+			case "t":
+				inText = false
+			}
+		default:
+		}
+	}
+
+	return
 }
@@ -77,3 +77,79 @@ func TestMakingPptxBadUrl(t *testing.T) {
 		t.Errorf("Expected to fail to open %s successfully", url)
 	}
 }
+
+func TestReadingPptxSlideOrder(t *testing.T) {
+	path := "../../test_data/slide_order.pptx"
+	ppt, err := MakePptx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	expected := []Slide{
+		{Number: 1, Part: "ppt/slides/slide10.xml", Text: "First"},
+		{Number: 2, Part: "ppt/slides/slide2.xml", Text: "Second"},
+		{Number: 3, Part: "ppt/slides/slide1.xml", Text: "Third"},
+	}
+
+	if len(ppt.Slides) != len(expected) || len(ppt.Text) != len(expected) {
+		t.Fatalf("Expected to have %d slides, has: %d", len(expected), len(ppt.Slides))
+	}
+
+	for i, slide := range expected {
+		if ppt.Slides[i] != slide {
+			t.Errorf("Expected slide %d to be: %v, was: %v", i, slide, ppt.Slides[i])
+		}
+
+		if ppt.Text[i] != slide.Text {
+			t.Errorf("Expected the text of slide %d to be: %s, was: %s", i, slide.Text, ppt.Text[i])
+		}
+	}
+}
+
+func TestReadingPptxNotes(t *testing.T) {
+	path := "../../test_data/notes.pptx"
+	ppt, err := MakePptx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	if len(ppt.Notes) != 2 || len(ppt.Slides) != 2 {
+		t.Fatalf("Expected to have notes for 2 slides, has: %d", len(ppt.Notes))
+	}
+
+	notes := "Remember to mention the bridge"
+	if ppt.Notes[0] != notes || ppt.Slides[0].Notes != notes {
+		t.Errorf("Expected the notes of the first slide to be: %q, was: %q", notes, ppt.Notes[0])
+	}
+
+	if ppt.Notes[1] != "" {
+		t.Errorf("Expected the second slide to have no notes, has: %q", ppt.Notes[1])
+	}
+}
+
+func TestReadingPptxWithUnreadableNotes(t *testing.T) {
+	path := "../../test_data/notes.pptx"
+	part := "ppt/notesSlides/notesSlide1.xml"
+
+	for _, data := range [][]byte{removingPart(t, path, part), replacingPart(t, path, part, "<p:notes><p:cSld>")} {
+		ppt, err := MakePptxFromBytes(data)
+		if err != nil {
+			t.Fatalf("Expected the unreadable notes to be skipped: %s", err.Error())
+		}
+
+		if len(ppt.Slides) != 2 || ppt.Notes[0] != "" || ppt.Slides[0].Notes != "" {
+			t.Errorf("Expected the slides without notes: %v", ppt.Slides)
+		}
+
+		if ppt.Slides[0].Text == "" {
+			t.Errorf("Expected the text of the first slide to be kept")
+		}
+	}
+
+	data := replacingPart(t, path, part, strings.Repeat("<a>", 100)+strings.Repeat("</a>", 100))
+	if _, err := MakePptxFromBytes(data, WithLimits(Limits{MaxXmlDepth: 50})); !isLimitError(err) {
+		t.Errorf("Expected a *LimitError, got: %v", err)
+	}
+}
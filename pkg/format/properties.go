@@ -0,0 +1,241 @@
+package format
+
+import (
+	"encoding/xml"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corePropertiesRelationshipType     = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	extendedPropertiesRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	customPropertiesRelationshipType   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+)
+
+// Properties contains the metadata of a document: the core properties
+// (docProps/core.xml), the extended properties written by the application
+// that saved the document (docProps/app.xml) and the custom properties
+// (docProps/custom.xml). The properties missing from the document are left
+// at their zero value. Pages and Words are only set for text documents and
+// Slides for presentations.
+type Properties struct {
+	Title          string
+	Subject        string
+	Creator        string
+	Keywords       string
+	Description    string
+	LastModifiedBy string
+	Created        time.Time
+	Modified       time.Time
+	Revision       string
+	Pages          int
+	Words          int
+	Slides         int
+	Application    string
+	Custom         []CustomProperty
+}
+
+// CustomProperty is a user defined property of a document. Value is a string,
+// an int64, a float64, a bool or a time.Time depending on the type of the
+// property in the document (types not listed here are returned as strings).
+type CustomProperty struct {
+	Name  string
+	Value interface{}
+}
+
+// readProperties reads the metadata of the package. The properties are not
+// essential to the contents of the document, so the parts that are missing or
+// cannot be parsed are skipped. Only the parts exceeding the limits of the
+// document are reported with an error.
+func readProperties(reader archive.ZipData, pkg *opc.Package) (Properties, error) {
+	var properties Properties
+
+	core, found, err := readPropertiesPart(reader, pkg, corePropertiesRelationshipType)
+	if err != nil {
+		return properties, err
+	}
+
+	if found {
+		if values, err := simpleElementsFromXml(core); err == nil {
+			properties.Title = values["title"]
+			properties.Subject = values["subject"]
+			properties.Creator = values["creator"]
+			properties.Keywords = values["keywords"]
+			properties.Description = values["description"]
+			properties.LastModifiedBy = values["lastModifiedBy"]
+			properties.Created, _ = time.Parse(time.RFC3339, values["created"])
+			properties.Modified, _ = time.Parse(time.RFC3339, values["modified"])
+			properties.Revision = values["revision"]
+		}
+	}
+
+	app, found, err := readPropertiesPart(reader, pkg, extendedPropertiesRelationshipType)
+	if err != nil {
+		return properties, err
+	}
+
+	if found {
+		if values, err := simpleElementsFromXml(app); err == nil {
+			properties.Pages, _ = strconv.Atoi(values["Pages"])
+			properties.Words, _ = strconv.Atoi(values["Words"])
+			properties.Slides, _ = strconv.Atoi(values["Slides"])
+			properties.Application = values["Application"]
+		}
+	}
+
+	custom, found, err := readPropertiesPart(reader, pkg, customPropertiesRelationshipType)
+	if err != nil {
+		return properties, err
+	}
+
+	if found {
+		properties.Custom, _ = customPropertiesFromXml(custom)
+	}
+
+	return properties, nil
+}
+
+// readPropertiesPart reads the part the package refers to with the given
+// relationship type. The second return value is false if the part is missing
+// or cannot be read, the error is only set if the part exceeds the limits of
+// the document.
+func readPropertiesPart(
+	reader archive.ZipData, pkg *opc.Package, relationshipType string,
+) (string, bool, error) {
+	matching := pkg.RelationshipsOfType("", relationshipType)
+	if len(matching) == 0 || matching[0].External {
+		return "", false, nil
+	}
+
+	partXml, err := ReadXml(reader, matching[0].TargetPart())
+	if isLimitError(err) {
+		return "", false, err
+	} else if err != nil {
+		return "", false, nil
+	}
+
+	return partXml, true, nil
+}
+
+// simpleElementsFromXml returns the text of the children of the root element
+// keyed by their local names.
+func simpleElementsFromXml(propertiesXml string) (values map[string]string, err error) {
+	var (
+		reader  = strings.NewReader(propertiesXml)
+		decoder = xml.NewDecoder(reader)
+		depth   int
+		name    string
+		text    strings.Builder
+	)
+
+	values = map[string]string{}
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if depth == 2 {
+				text.Write(t)
+			}
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				name = t.Name.Local
+				text.Reset()
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				values[name] = strings.TrimSpace(text.String())
+			}
+			depth--
+		default:
+		}
+	}
+
+	return
+}
+
+// customPropertiesFromXml parses docProps/custom.xml.
+func customPropertiesFromXml(customXml string) (properties []CustomProperty, err error) {
+	var (
+		reader    = strings.NewReader(customXml)
+		decoder   = xml.NewDecoder(reader)
+		property  *CustomProperty
+		valueType string
+		value     strings.Builder
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if property != nil && valueType != "" {
+				value.Write(t)
+			}
+		case xml.StartElement:
+			if t.Name.Local == "property" {
+				property = &CustomProperty{Name: AttrValue(t, "name")}
+			} else if property != nil && valueType == "" {
+				valueType = t.Name.Local
+				value.Reset()
+			}
+		case xml.EndElement:
+			if t.Name.Local == "property" && property != nil {
+				properties = append(properties, *property)
+				property = nil
+			} else if property != nil && t.Name.Local == valueType {
+				property.Value = typedValue(valueType, value.String())
+				valueType = ""
+			}
+		default:
+		}
+	}
+
+	return
+}
+
+// typedValue converts the text of a custom property to the Go type matching
+// its variant type (vt:lpwstr, vt:i4, vt:bool, etc.).
+func typedValue(valueType string, text string) interface{} {
+	switch valueType {
+	case "i1", "i2", "i4", "i8", "int", "ui1", "ui2", "ui4", "ui8", "uint":
+		if n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+			return n
+		}
+	case "r4", "r8", "decimal":
+		if f, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return f
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return b
+		}
+	case "filetime", "date":
+		if d, err := time.Parse(time.RFC3339, strings.TrimSpace(text)); err == nil {
+			return d
+		}
+	}
+
+	return text
+}
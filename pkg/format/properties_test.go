@@ -0,0 +1,89 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadingProperties(t *testing.T) {
+	path := "../../test_data/properties.docx"
+	doc, err := MakeDocx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	properties := doc.Properties
+
+	if properties.Title != "Quarterly report" || properties.Subject != "Sales" ||
+		properties.Creator != "Jane Doe" || properties.Keywords != "sales, report" ||
+		properties.Description != "Figures of the third quarter" || properties.LastModifiedBy != "John Roe" {
+		t.Errorf("Unexpected core properties: %v", properties)
+	}
+
+	if !properties.Created.Equal(time.Date(2023, 7, 1, 9, 30, 0, 0, time.UTC)) ||
+		!properties.Modified.Equal(time.Date(2023, 7, 2, 16, 45, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected dates: %v, %v", properties.Created, properties.Modified)
+	}
+
+	if properties.Revision != "3" || properties.Pages != 2 || properties.Words != 4 ||
+		properties.Application != "Microsoft Office Word" {
+		t.Errorf("Unexpected extended properties: %v", properties)
+	}
+
+	expected := []CustomProperty{
+		{Name: "Department", Value: "Sales"},
+		{Name: "Quarter", Value: int64(3)},
+		{Name: "Approved", Value: true},
+		{Name: "Budget", Value: 1250.5},
+		{Name: "Deadline", Value: time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if len(properties.Custom) != len(expected) {
+		t.Fatalf("Expected %d custom properties, found: %v", len(expected), properties.Custom)
+	}
+
+	for i, e := range expected {
+		if properties.Custom[i] != e {
+			t.Errorf("Expected custom property: %v, found: %v", e, properties.Custom[i])
+		}
+	}
+}
+
+func TestReadingPropertiesOfAllFormats(t *testing.T) {
+	for path, created := range map[string]time.Time{
+		"../../test_data/example.docx": time.Date(2022, 4, 20, 11, 41, 13, 0, time.UTC),
+		"../../test_data/example.pptx": time.Date(2022, 4, 21, 16, 54, 21, 0, time.UTC),
+		"../../test_data/example.xlsx": time.Date(2022, 4, 22, 10, 2, 21, 0, time.UTC),
+	} {
+		doc, err := Open(path)
+
+		if err != nil {
+			t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+		}
+
+		var properties Properties
+
+		switch d := doc.(type) {
+		case *Docx:
+			properties = d.Properties
+		case *Pptx:
+			properties = d.Properties
+		case *Xlsx:
+			properties = d.Properties
+		}
+
+		if !properties.Created.Equal(created) || properties.Revision == "" {
+			t.Errorf("Unexpected properties of %s: %v", path, properties)
+		}
+
+		if len(properties.Custom) != 0 {
+			t.Errorf("Expected %s to have no custom properties, has: %v", path, properties.Custom)
+		}
+	}
+
+	doc, _ := MakeDocx("../../test_data/example.docx")
+	if doc.Properties.Pages != 1 || doc.Properties.Words != 370 {
+		t.Errorf("Unexpected page and word count: %d, %d", doc.Properties.Pages, doc.Properties.Words)
+	}
+}
@@ -0,0 +1,67 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadingStrictDocx(t *testing.T) {
+	expected, _ := MakeDocx("../../test_data/links.docx")
+
+	doc, err := MakeDocx("../../test_data/strict_links.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the Strict document: %s", err.Error())
+	}
+
+	if doc.Text != expected.Text {
+		t.Errorf("Expected the text of the Strict document to be:\n%s\nwas:\n%s", expected.Text, doc.Text)
+	}
+
+	if len(doc.Links) == 0 || !reflect.DeepEqual(doc.Links, expected.Links) {
+		t.Errorf("Expected the links of the Strict document to be: %v, was: %v", expected.Links, doc.Links)
+	}
+}
+
+func TestReadingStrictProperties(t *testing.T) {
+	expected, _ := MakeDocx("../../test_data/properties.docx")
+
+	doc, err := MakeDocx("../../test_data/strict_properties.docx")
+	if err != nil {
+		t.Fatalf("Failed to read the Strict document: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(doc.Properties, expected.Properties) {
+		t.Errorf("Expected the properties of the Strict document to be: %v, was: %v", expected.Properties, doc.Properties)
+	}
+}
+
+func TestReadingStrictPptx(t *testing.T) {
+	expected, _ := MakePptx("../../test_data/notes.pptx")
+
+	doc, err := Open("../../test_data/strict.pptx")
+	if err != nil {
+		t.Fatalf("Failed to read the Strict presentation: %s", err.Error())
+	}
+
+	pptx, ok := doc.(*Pptx)
+	if !ok {
+		t.Fatalf("Expected a *Pptx, got: %T", doc)
+	}
+
+	if !reflect.DeepEqual(pptx.Slides, expected.Slides) {
+		t.Errorf("Expected the slides of the Strict presentation to be: %v, was: %v", expected.Slides, pptx.Slides)
+	}
+}
+
+func TestReadingStrictXlsx(t *testing.T) {
+	expected, _ := MakeXlsx("../../test_data/example.xlsx")
+
+	doc, err := MakeXlsx("../../test_data/strict.xlsx")
+	if err != nil {
+		t.Fatalf("Failed to read the Strict workbook: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(doc.Sheets, expected.Sheets) {
+		t.Errorf("Expected the sheets of the Strict workbook to be: %v, was: %v", expected.Sheets, doc.Sheets)
+	}
+}
@@ -0,0 +1,158 @@
+package format
+
+import (
+	"github.com/nagygr/ooxml2txt/internal/opc"
+)
+
+// vbaProjectRelationshipType is the type of the relationship pointing from the
+// main part to the VBA project (the macros) of the document.
+const vbaProjectRelationshipType = "http://schemas.microsoft.com/office/2006/relationships/vbaProject"
+
+// Variant tells which variant of its format a document is: the plain document,
+// the macro-enabled one, the template, etc. The variants are extracted exactly
+// like their base formats (see Kind).
+type Variant int
+
+const (
+	// VariantUnknown is the variant of packages that are not recognized.
+	VariantUnknown Variant = iota
+	// VariantDocx is the plain text document.
+	VariantDocx
+	// VariantDocm is the macro-enabled text document.
+	VariantDocm
+	// VariantDotx is the text document template.
+	VariantDotx
+	// VariantDotm is the macro-enabled text document template.
+	VariantDotm
+	// VariantPptx is the plain presentation.
+	VariantPptx
+	// VariantPptm is the macro-enabled presentation.
+	VariantPptm
+	// VariantPotx is the presentation template.
+	VariantPotx
+	// VariantPotm is the macro-enabled presentation template.
+	VariantPotm
+	// VariantPpsx is the slide show (a presentation that opens in
+	// presentation mode).
+	VariantPpsx
+	// VariantPpsm is the macro-enabled slide show.
+	VariantPpsm
+	// VariantXlsx is the plain spreadsheet.
+	VariantXlsx
+	// VariantXlsm is the macro-enabled spreadsheet.
+	VariantXlsm
+	// VariantXltx is the spreadsheet template.
+	VariantXltx
+	// VariantXltm is the macro-enabled spreadsheet template.
+	VariantXltm
+)
+
+// variantInfo describes a variant: its format, its usual file extension, the
+// content type of its main part and whether it can contain macros.
+type variantInfo struct {
+	kind         Kind
+	extension    string
+	contentType  string
+	macroEnabled bool
+}
+
+var variantInfos = [...]variantInfo{
+	VariantUnknown: {KindUnknown, "unknown", "", false},
+	VariantDocx: {
+		KindDocx, "docx",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml", false,
+	},
+	VariantDocm: {KindDocx, "docm", "application/vnd.ms-word.document.macroEnabled.main+xml", true},
+	VariantDotx: {
+		KindDocx, "dotx",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml", false,
+	},
+	VariantDotm: {KindDocx, "dotm", "application/vnd.ms-word.template.macroEnabledTemplate.main+xml", true},
+	VariantPptx: {
+		KindPptx, "pptx",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml", false,
+	},
+	VariantPptm: {KindPptx, "pptm", "application/vnd.ms-powerpoint.presentation.macroEnabled.main+xml", true},
+	VariantPotx: {
+		KindPptx, "potx",
+		"application/vnd.openxmlformats-officedocument.presentationml.template.main+xml", false,
+	},
+	VariantPotm: {KindPptx, "potm", "application/vnd.ms-powerpoint.template.macroEnabled.main+xml", true},
+	VariantPpsx: {
+		KindPptx, "ppsx",
+		"application/vnd.openxmlformats-officedocument.presentationml.slideshow.main+xml", false,
+	},
+	VariantPpsm: {KindPptx, "ppsm", "application/vnd.ms-powerpoint.slideshow.macroEnabled.main+xml", true},
+	VariantXlsx: {
+		KindXlsx, "xlsx",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml", false,
+	},
+	VariantXlsm: {KindXlsx, "xlsm", "application/vnd.ms-excel.sheet.macroEnabled.main+xml", true},
+	VariantXltx: {
+		KindXlsx, "xltx",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.template.main+xml", false,
+	},
+	VariantXltm: {KindXlsx, "xltm", "application/vnd.ms-excel.template.macroEnabled.main+xml", true},
+}
+
+// info returns the description of the variant.
+func (v Variant) info() variantInfo {
+	if v < 0 || int(v) >= len(variantInfos) {
+		return variantInfos[VariantUnknown]
+	}
+
+	return variantInfos[v]
+}
+
+// String returns the usual file extension of the variant.
+func (v Variant) String() string {
+	return v.info().extension
+}
+
+// Kind returns the format of the variant.
+func (v Variant) Kind() Kind {
+	return v.info().kind
+}
+
+// MacroEnabled tells whether documents of the variant may contain macros.
+func (v Variant) MacroEnabled() bool {
+	return v.info().macroEnabled
+}
+
+// ContentType returns the content type of the main part of the variant.
+func (v Variant) ContentType() string {
+	return v.info().contentType
+}
+
+// variantOfContentType maps the content type of the main part of a package to
+// the variant of the document.
+func variantOfContentType(contentType string) Variant {
+	for v, info := range variantInfos {
+		if info.contentType != "" && info.contentType == contentType {
+			return Variant(v)
+		}
+	}
+
+	return VariantUnknown
+}
+
+// readVariant returns the variant of the document based on the content type of
+// its main part. Packages whose main part has no content type of the given
+// base variant's format are reported as the base variant.
+func readVariant(pkg *opc.Package, mainPart string, base Variant) Variant {
+	part, found := pkg.Part(mainPart)
+	if !found {
+		return base
+	}
+
+	if v := variantOfContentType(part.ContentType); v.Kind() == base.Kind() {
+		return v
+	}
+
+	return base
+}
+
+// hasVbaProject tells whether the main part refers to a VBA project.
+func hasVbaProject(pkg *opc.Package, mainPart string) bool {
+	return len(pkg.RelationshipsOfType(mainPart, vbaProjectRelationshipType)) > 0
+}
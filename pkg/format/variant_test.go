@@ -0,0 +1,77 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpeningVariants(t *testing.T) {
+	expected := []struct {
+		path    string
+		variant Variant
+		kind    Kind
+		vba     bool
+	}{
+		{"../../test_data/example.docx", VariantDocx, KindDocx, false},
+		{"../../test_data/macros.docm", VariantDocm, KindDocx, true},
+		{"../../test_data/example.dotx", VariantDotx, KindDocx, false},
+		{"../../test_data/example.pptx", VariantPptx, KindPptx, false},
+		{"../../test_data/example.ppsx", VariantPpsx, KindPptx, false},
+		{"../../test_data/example.xlsx", VariantXlsx, KindXlsx, false},
+		{"../../test_data/macros.xlsm", VariantXlsm, KindXlsx, true},
+	}
+
+	for _, e := range expected {
+		doc, err := Open(e.path)
+		if err != nil {
+			t.Errorf("Expected to open %s successfully: %s", e.path, err.Error())
+			continue
+		}
+
+		if doc.Kind() != e.kind || doc.Variant() != e.variant {
+			t.Errorf("Expected %s to be a %s (%s), was: %s (%s)", e.path, e.variant, e.kind, doc.Variant(), doc.Kind())
+		}
+
+		if doc.HasVbaProject() != e.vba {
+			t.Errorf("Expected HasVbaProject of %s to be %t", e.path, e.vba)
+		}
+	}
+}
+
+func TestReadingVariantsLikeTheirBaseFormat(t *testing.T) {
+	docx, _ := MakeDocx("../../test_data/example.docx")
+	docm, err := MakeDocx("../../test_data/macros.docm")
+
+	if err != nil {
+		t.Fatalf("Failed to read the docm document: %s", err.Error())
+	}
+
+	if docm.Text != docx.Text || docm.Variant() != VariantDocm {
+		t.Errorf("Expected the docm document to have the text of the docx one")
+	}
+
+	pptx, _ := MakePptx("../../test_data/example.pptx")
+	ppsx, err := MakePptx("../../test_data/example.ppsx")
+
+	if err != nil {
+		t.Fatalf("Failed to read the ppsx document: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(ppsx.Text, pptx.Text) || ppsx.Variant() != VariantPpsx {
+		t.Errorf("Expected the ppsx document to have the text of the pptx one")
+	}
+}
+
+func TestVariantProperties(t *testing.T) {
+	if VariantXltm.Kind() != KindXlsx || !VariantXltm.MacroEnabled() || VariantXltm.String() != "xltm" {
+		t.Errorf("Unexpected properties of VariantXltm")
+	}
+
+	if VariantDotx.MacroEnabled() || VariantDotx.String() != "dotx" {
+		t.Errorf("Unexpected properties of VariantDotx")
+	}
+
+	if Variant(100).Kind() != KindUnknown || Variant(100).String() != "unknown" {
+		t.Errorf("Expected invalid variants to be unknown")
+	}
+}
@@ -1,65 +1,291 @@
 package format
 
 import (
+	"context"
+	"fmt"
 	"github.com/nagygr/ooxml2txt/internal/archive"
 	. "github.com/nagygr/ooxml2txt/internal/format"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+	"io/fs"
+)
+
+const (
+	xlsxMainPart = "xl/workbook.xml"
+
+	worksheetRelationshipType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"
+	sharedStringsRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"
 )
 
 // Xlsx handles xlsx documents. The Text member is a list of strings where each
 // element corresponds to a string value in the document. Only the strings are
 // collected, numbers, formulas and binary data is ignored. Only unique strings
 // are collected, i.e. if a piece of text appears multiple times in the
-// document, it will only show up once in the list.
+// document, it will only show up once in the list. The list starts with the
+// shared string table of the document followed by the strings that are stored
+// in the worksheets themselves (inline strings), so documents without a shared
+// string table are also supported. Sheets contains the
+// worksheets of the document in the order of their tabs with every non-empty
+// cell (including numbers and repeated strings) addressed by its reference.
+// Properties contains the metadata of the workbook. Macro-enabled workbooks and
+// templates (xlsm, xltx, xltm) are handled the same way, their Variant method
+// tells which one the document is.
 type Xlsx struct {
-	zipReader archive.ZipData
-	Text      []string
+	Text       []string
+	Sheets     []Sheet
+	Properties Properties
+
+	variant    Variant
+	vbaProject bool
 }
 
 // MakeXlsx creates a Xlsx from the path to a spreadsheet document. The
 // returned instance contains the valid contents of the document if there was
 // no error while processing it (which is then reported in the returned error
 // value).
-func MakeXlsx(path string) (*Xlsx, error) {
-	reader, err := archive.MakeZipFile(path)
+func MakeXlsx(path string, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFile(path, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makeXlsxFromReader(reader)
+	return makeXlsxFromReader(reader, nil, settings)
 }
 
 // MakeXlsxFromUrl creates a Xlsx from an URL to a spreadsheet document. The
 // returned instance contains the valid contents of the document if there was
 // no error while processing it (which is then reported in the returned error
 // value).
-func MakeXlsxFromUrl(url string) (*Xlsx, error) {
-	reader, err := archive.MakeZipFileFromUrl(url)
+func MakeXlsxFromUrl(url string, opts ...Option) (*Xlsx, error) {
+	return MakeXlsxFromUrlContext(context.Background(), url, opts...)
+}
+
+// MakeXlsxFromUrlContext creates a Xlsx from an URL to a spreadsheet document.
+// The download is bound to the given context and can be configured with the
+// WithHttpClient, WithHttpHeader and WithMaxDownloadSize options. Responses
+// with a status code other than 2xx are reported as errors.
+func MakeXlsxFromUrlContext(ctx context.Context, url string, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromUrlContext(ctx, url, settings.urlOptions())
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makeXlsxFromReader(reader, nil, settings)
+}
+
+// MakeXlsxFromReader creates a Xlsx from a spreadsheet document held by the
+// given reader (size is the length of the document in bytes). The reader is
+// only used during the call, the document is fully read by the time it
+// returns.
+func MakeXlsxFromReader(readerAt io.ReaderAt, size int64, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromReaderAt(readerAt, size, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makeXlsxFromReader(reader, nil, settings)
+}
+
+// MakeXlsxFromBytes creates a Xlsx from a spreadsheet document held in memory.
+func MakeXlsxFromBytes(data []byte, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromBytes(data, settings.password)
+
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return makeXlsxFromReader(reader, nil, settings)
+}
+
+// MakeXlsxFromFS creates a Xlsx from the spreadsheet document called name in
+// the given file system (e.g. an embed.FS or the result of os.DirFS).
+func MakeXlsxFromFS(fsys fs.FS, name string, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromFS(fsys, name, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	return makeXlsxFromReader(reader)
+	return makeXlsxFromReader(reader, nil, settings)
 }
 
-func makeXlsxFromReader(reader archive.ZipData) (*Xlsx, error) {
-	sharedStringsXml, err := ReadXml(reader, "xl/sharedStrings.xml")
+// MakeXlsxFromStream creates a Xlsx from a spreadsheet document read from the
+// given reader (e.g. an HTTP request body). Only documents smaller than the
+// spool threshold (see WithSpoolThreshold) are held in memory, larger ones are
+// buffered in a temporary file.
+func MakeXlsxFromStream(stream io.Reader, opts ...Option) (*Xlsx, error) {
+	settings := makeOptions(opts)
+	reader, err := archive.MakeZipFileFromStream(stream, settings.spoolThreshold, settings.password)
 
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	var sharedStrings []string
+	return makeXlsxFromReader(reader, nil, settings)
+}
 
-	sharedStrings, err = XlsxSharedStringsFromXml(sharedStringsXml)
+// Kind returns KindXlsx.
+func (x *Xlsx) Kind() Kind {
+	return KindXlsx
+}
+
+// Variant returns the variant of the document (VariantXlsx, VariantXlsm,
+// VariantXltx or VariantXltm).
+func (x *Xlsx) Variant() Variant {
+	return x.variant
+}
+
+// HasVbaProject tells whether the workbook carries a VBA project.
+func (x *Xlsx) HasVbaProject() bool {
+	return x.vbaProject
+}
+
+// makeXlsxFromReader processes the archive with the given settings. If pkg is
+// nil, the model of the package is read from the archive. The main part is
+// looked up in the package (xl/workbook.xml is used for packages that don't
+// declare it).
+func makeXlsxFromReader(reader archive.ZipData, pkg *opc.Package, settings options) (*Xlsx, error) {
+	if pkg == nil {
+		var err error
+		if pkg, err = readPackage(reader, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	mainPart := mainPartOrDefault(pkg, xlsxMainPart)
+
+	if _, err := pkg.Relationships(mainPart); err != nil {
+		return nil, err
+	}
+
+	sharedStrings, err := readSharedStrings(reader, pkg, mainPart)
 
 	if err != nil {
 		return nil, err
 	}
 
+	sheets, err := readSheets(reader, pkg, mainPart, sharedStrings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := readProperties(reader, pkg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Xlsx{
-		zipReader: reader,
-		Text:      sharedStrings}, nil
+		Text:       appendSheetStrings(sharedStrings, sheets),
+		Sheets:     sheets,
+		Properties: properties,
+		variant:    readVariant(pkg, mainPart, VariantXlsx),
+		vbaProject: hasVbaProject(pkg, mainPart)}, nil
+}
+
+// readSharedStrings reads the shared string table of the workbook. Workbooks
+// that only use inline strings (or no strings at all) have no shared string
+// table, an empty list is returned for them.
+func readSharedStrings(reader archive.ZipData, pkg *opc.Package, mainPart string) ([]string, error) {
+	sharedStringsPart := "xl/sharedStrings.xml"
+	if r := pkg.RelationshipsOfType(mainPart, sharedStringsRelationshipType); len(r) > 0 && !r[0].External {
+		sharedStringsPart = r[0].TargetPart()
+	}
+
+	if _, found := pkg.Part(sharedStringsPart); !found {
+		return []string{}, nil
+	}
+
+	sharedStringsXml, err := ReadXml(reader, sharedStringsPart)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sharedStrings, err := XlsxSharedStringsFromXml(sharedStringsXml)
+	return sharedStrings, InPart(err, sharedStringsPart)
+}
+
+// appendSheetStrings appends the text of the string cells that are not in the
+// shared string table (inline strings and the results of formulas) to the
+// list of strings. Every string is only added once.
+func appendSheetStrings(texts []string, sheets []Sheet) []string {
+	known := map[string]bool{}
+	for _, s := range texts {
+		known[s] = true
+	}
+
+	for _, sheet := range sheets {
+		for _, cell := range sheet.Cells {
+			if cell.Type == CellString && !known[cell.Value] {
+				known[cell.Value] = true
+				texts = append(texts, cell.Value)
+			}
+		}
+	}
+
+	return texts
+}
+
+// readSheets reads the worksheets listed in the workbook in order.
+func readSheets(
+	reader archive.ZipData, pkg *opc.Package, mainPart string, sharedStrings []string,
+) ([]Sheet, error) {
+	workbookXml, err := ReadXml(reader, mainPart)
+	if err != nil {
+		return nil, err
+	}
+
+	workbookSheets, err := workbookSheetsFromXml(workbookXml)
+	if err != nil {
+		return nil, InPart(err, mainPart)
+	}
+
+	sheets := []Sheet{}
+
+	for _, s := range workbookSheets {
+		r, found := pkg.Relationship(mainPart, s.relationshipId)
+
+		if !found {
+			return nil, &archive.Error{
+				Kind:    ErrMissingPart,
+				Message: fmt.Sprintf("The relationship %s of sheet %s not found", s.relationshipId, s.name),
+			}
+		}
+
+		if r.Type != worksheetRelationshipType || r.External {
+			// Chart sheets and dialog sheets contain no cells.
+			continue
+		}
+
+		part := r.TargetPart()
+
+		sheetXml, err := ReadXml(reader, part)
+		if err != nil {
+			return nil, err
+		}
+
+		cells, err := cellsFromWorksheetXml(sheetXml, sharedStrings)
+		if err != nil {
+			return nil, InPart(err, part)
+		}
+
+		sheets = append(sheets, Sheet{Name: s.name, Part: part, Cells: cells})
+	}
 
+	return sheets, nil
 }
@@ -0,0 +1,273 @@
+package format
+
+import (
+	"encoding/xml"
+	. "github.com/nagygr/ooxml2txt/internal/format"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CellType is the type of the value of a spreadsheet cell.
+type CellType int
+
+const (
+	// CellNumber is the type of numeric cells.
+	CellNumber CellType = iota
+	// CellString is the type of cells containing text (shared strings,
+	// inline strings and formulas resulting in text).
+	CellString
+	// CellBoolean is the type of boolean cells, their value is either TRUE
+	// or FALSE.
+	CellBoolean
+	// CellError is the type of cells containing an error (e.g. #DIV/0!).
+	CellError
+	// CellDate is the type of cells containing an ISO 8601 date.
+	CellDate
+)
+
+// Cell is a non-empty cell of a worksheet. Ref is its address in A1 notation,
+// Row and Column are its 1-based coordinates. Value contains the value of the
+// cell as it is stored in the document (shared string indices are resolved
+// to the actual text).
+type Cell struct {
+	Ref    string
+	Row    int
+	Column int
+	Type   CellType
+	Value  string
+}
+
+// Sheet is a worksheet of a spreadsheet document. Name is the name of the
+// sheet as it appears on its tab, Part is the name of the part holding it
+// inside the package and Cells lists its non-empty cells row by row.
+type Sheet struct {
+	Name  string
+	Part  string
+	Cells []Cell
+}
+
+// Cell returns the cell of the sheet with the given A1 reference (e.g. "B2").
+// The second return value is false if the cell is empty or the reference is
+// invalid.
+func (s *Sheet) Cell(ref string) (Cell, bool) {
+	column, row, ok := parseCellRef(ref)
+	if !ok {
+		return Cell{}, false
+	}
+
+	for _, c := range s.Cells {
+		if c.Row == row && c.Column == column {
+			return c, true
+		}
+	}
+
+	return Cell{}, false
+}
+
+// workbookSheet is an entry of the sheets list of workbook.xml.
+type workbookSheet struct {
+	name           string
+	relationshipId string
+}
+
+// workbookSheetsFromXml returns the sheets listed in workbook.xml in order.
+func workbookSheetsFromXml(workbookXml string) (sheets []workbookSheet, err error) {
+	var (
+		reader  = strings.NewReader(workbookXml)
+		decoder = xml.NewDecoder(reader)
+	)
+
+	for {
+		token, decErr := decoder.Token()
+
+		if decErr == io.EOF {
+			break
+		} else if decErr != nil {
+			err = XmlErrorAt(decoder, decErr)
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "sheet" {
+				sheets = append(sheets, workbookSheet{
+					name:           AttrValue(t, "name"),
+					relationshipId: RelationshipIdAttr(t),
+				})
+			}
+		default:
+		}
+	}
+
+	return
+}
+
+// worksheetParser holds the state of parsing the cells of a worksheet.
+type worksheetParser struct {
+	sharedStrings []string
+	cells         []Cell
+	row           int
+	column        int
+	cellType      string
+	inValue       bool
+	inInline      bool
+	inText        bool
+	phoneticDepth int
+	value         strings.Builder
+}
+
+// cellsFromWorksheetXml parses the cells of a worksheet. Shared string
+// references are resolved from the given shared strings, references that are
+// out of range leave the value of the cell empty.
+func cellsFromWorksheetXml(sheetXml string, sharedStrings []string) ([]Cell, error) {
+	var (
+		reader  = strings.NewReader(sheetXml)
+		decoder = xml.NewDecoder(reader)
+		parser  = worksheetParser{sharedStrings: sharedStrings}
+	)
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, XmlErrorAt(decoder, err)
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if parser.inValue || (parser.inText && parser.phoneticDepth == 0) {
+				parser.value.Write(t)
+			}
+		case xml.StartElement:
+			parser.start(t)
+		case xml.EndElement:
+			parser.end(t)
+		default:
+		}
+	}
+
+	return parser.cells, nil
+}
+
+func (p *worksheetParser) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "row":
+		if row, err := strconv.Atoi(AttrValue(t, "r")); err == nil {
+			p.row = row
+		} else {
+			p.row++
+		}
+		p.column = 0
+	case "c":
+		if column, row, ok := parseCellRef(AttrValue(t, "r")); ok {
+			p.column, p.row = column, row
+		} else {
+			p.column++
+		}
+		p.cellType = AttrValue(t, "t")
+		p.value.Reset()
+	case "v":
+		p.inValue = true
+	case "is":
+		p.inInline = true
+	case "t":
+		p.inText = p.inInline
+	case "rPh":
+		p.phoneticDepth++
+	}
+}
+
+func (p *worksheetParser) end(t xml.EndElement) {
+	switch t.Name.Local {
+	case "c":
+		p.endCell()
+	case "v":
+		p.inValue = false
+	case "is":
+		p.inInline = false
+	case "t":
+		p.inText = false
+	case "rPh":
+		p.phoneticDepth--
+	}
+}
+
+func (p *worksheetParser) endCell() {
+	var (
+		value    = p.value.String()
+		cellType = CellNumber
+	)
+
+	switch p.cellType {
+	case "s":
+		cellType = CellString
+		index, err := strconv.Atoi(strings.TrimSpace(value))
+		if err == nil && index >= 0 && index < len(p.sharedStrings) {
+			value = p.sharedStrings[index]
+		} else {
+			value = ""
+		}
+	case "inlineStr", "str":
+		cellType = CellString
+	case "b":
+		cellType = CellBoolean
+		if strings.TrimSpace(value) == "1" {
+			value = "TRUE"
+		} else if value != "" {
+			value = "FALSE"
+		}
+	case "e":
+		cellType = CellError
+	case "d":
+		cellType = CellDate
+	}
+
+	if value == "" {
+		return
+	}
+
+	p.cells = append(p.cells, Cell{
+		Ref:    cellRef(p.column, p.row),
+		Row:    p.row,
+		Column: p.column,
+		Type:   cellType,
+		Value:  value,
+	})
+}
+
+// parseCellRef splits an A1 style reference into its 1-based column and row
+// numbers. Absolute references (e.g. $A$1) are accepted too.
+func parseCellRef(ref string) (column int, row int, ok bool) {
+	ref = strings.ToUpper(strings.ReplaceAll(ref, "$", ""))
+
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		column = column*26 + int(ref[i]-'A'+1)
+	}
+
+	if i == 0 || i == len(ref) {
+		return 0, 0, false
+	}
+
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row < 1 {
+		return 0, 0, false
+	}
+
+	return column, row, true
+}
+
+// cellRef returns the A1 style reference of the cell with the given 1-based
+// column and row numbers.
+func cellRef(column int, row int) string {
+	var name []byte
+
+	for ; column > 0; column = (column - 1) / 26 {
+		name = append([]byte{byte('A' + (column-1)%26)}, name...)
+	}
+
+	return string(name) + strconv.Itoa(row)
+}
@@ -1,6 +1,7 @@
 package format
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -75,3 +76,127 @@ func TestMakingXlsxBadUrl(t *testing.T) {
 		t.Errorf("Expected to fail to open %s successfully", url)
 	}
 }
+
+func TestReadingXlsxSheets(t *testing.T) {
+	path := "../../test_data/example.xlsx"
+	xls, err := MakeXlsx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully", path)
+	}
+
+	if len(xls.Sheets) != 1 || xls.Sheets[0].Name != "Sheet1" {
+		t.Fatalf("Expected to have one sheet called Sheet1, has: %v", xls.Sheets)
+	}
+
+	sheet := xls.Sheets[0]
+	if len(sheet.Cells) != 5 {
+		t.Errorf("Expected to have 5 cells, has: %d", len(sheet.Cells))
+	}
+
+	if cell, ok := sheet.Cell("A1"); !ok || cell.Value != "Odense" || cell.Type != CellString {
+		t.Errorf("Expected A1 to be the string Odense, was: %v", cell)
+	}
+
+	if cell, ok := sheet.Cell("B3"); !ok || cell.Value != "2" || cell.Type != CellNumber {
+		t.Errorf("Expected B3 to be the number 2, was: %v", cell)
+	}
+}
+
+func TestReadingXlsxCellTypes(t *testing.T) {
+	path := "../../test_data/cells.xlsx"
+	xls, err := MakeXlsx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully", path)
+	}
+
+	if len(xls.Sheets) != 2 || xls.Sheets[0].Name != "Cities" || xls.Sheets[1].Name != "Other" {
+		t.Fatalf("Expected to have the sheets Cities and Other, has: %v", xls.Sheets)
+	}
+
+	expected := []Cell{
+		{Ref: "A1", Row: 1, Column: 1, Type: CellString, Value: "City"},
+		{Ref: "B1", Row: 1, Column: 2, Type: CellString, Value: "Population"},
+		{Ref: "A2", Row: 2, Column: 1, Type: CellString, Value: "City"},
+		{Ref: "B2", Row: 2, Column: 2, Type: CellNumber, Value: "3.5"},
+		{Ref: "A3", Row: 3, Column: 1, Type: CellString, Value: "Inline rich"},
+		{Ref: "B3", Row: 3, Column: 2, Type: CellBoolean, Value: "TRUE"},
+		{Ref: "C3", Row: 3, Column: 3, Type: CellError, Value: "#DIV/0!"},
+		{Ref: "A4", Row: 4, Column: 1, Type: CellString, Value: "CityPopulation"},
+		{Ref: "C4", Row: 4, Column: 3, Type: CellNumber, Value: "42"},
+	}
+
+	cells := xls.Sheets[0].Cells
+	if len(cells) != len(expected) {
+		t.Fatalf("Expected to have %d cells, has: %d", len(expected), len(cells))
+	}
+
+	for i, cell := range expected {
+		if cells[i] != cell {
+			t.Errorf("Expected cell %d to be: %v, was: %v", i, cell, cells[i])
+		}
+	}
+
+	if cell, ok := xls.Sheets[1].Cell("$D$5"); !ok || cell.Value != "Population" {
+		t.Errorf("Expected D5 of the second sheet to be Population, was: %v", cell)
+	}
+}
+
+func TestReadingXlsxWithoutSharedStrings(t *testing.T) {
+	path := "../../test_data/inline_strings.xlsx"
+	xls, err := MakeXlsx(path)
+
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	if len(xls.Text) != 2 || xls.Text[0] != "Aarhus" || xls.Text[1] != "Aalborg" {
+		t.Errorf("Expected the unique inline strings as text, got: %v", xls.Text)
+	}
+
+	if len(xls.Sheets) != 1 || len(xls.Sheets[0].Cells) != 5 {
+		t.Fatalf("Expected to have one sheet with 5 cells, has: %v", xls.Sheets)
+	}
+
+	if cell, ok := xls.Sheets[0].Cell("B2"); !ok || cell.Value != "119862" {
+		t.Errorf("Expected B2 to be 119862, was: %v", cell)
+	}
+}
+
+func TestReadingXlsxFromStream(t *testing.T) {
+	path := "../../test_data/example.xlsx"
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %s", path, err.Error())
+	}
+	defer file.Close()
+
+	doc, err := MakeXlsxFromStream(file, WithSpoolThreshold(512))
+	if err != nil {
+		t.Fatalf("Expected to read %s from a stream: %s", path, err.Error())
+	}
+
+	expected, _ := MakeXlsx(path)
+	if strings.Join(doc.Text, "|") != strings.Join(expected.Text, "|") {
+		t.Errorf("Expected the same strings as from the file, got: %v", doc.Text)
+	}
+}
+
+func TestReadingXlsxPartNamesIgnoringCase(t *testing.T) {
+	path := "../../test_data/example.xlsx"
+
+	expected, err := MakeXlsx(path)
+	if err != nil {
+		t.Fatalf("Expected to open %s successfully: %s", path, err.Error())
+	}
+
+	xls, err := MakeXlsxFromBytes(renamingPart(t, path, "xl/sharedStrings.xml", "xl/SharedStrings.xml"))
+	if err != nil {
+		t.Fatalf("Expected the renamed shared strings to be found: %s", err.Error())
+	}
+
+	if strings.Join(xls.Text, "|") != strings.Join(expected.Text, "|") {
+		t.Errorf("Expected the text to be: %v, was: %v", expected.Text, xls.Text)
+	}
+}
@@ -0,0 +1,53 @@
+// Package opc contains the model of OPC (Open Packaging Conventions) packages,
+// the container format of the OOXML documents: the parts of the package with
+// their content types (from [Content_Types].xml) and the relationships
+// between them (from the _rels/*.rels parts).
+package opc
+
+import (
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"github.com/nagygr/ooxml2txt/internal/opc"
+	"io"
+)
+
+// Part is a part of a package. Name is the name of the part without the
+// leading slash (e.g. word/document.xml) and ContentType is its content type
+// as given by [Content_Types].xml.
+type Part = opc.Part
+
+// Relationship is a relationship of a part (or of the package itself, in which
+// case Source is empty). Target is the target as written in the relationships
+// part: an URL for external relationships, a part name relative to the source
+// part otherwise (see TargetPart). The types of Strict documents are given in
+// their Transitional form.
+type Relationship = opc.Relationship
+
+// Package is the model of a package: its parts and the relationships between
+// them. The part names are case-insensitive. Packages without
+// [Content_Types].xml are reported with an error of the ErrNotOoxml kind (see
+// format.ErrNotOoxml).
+type Package = opc.Package
+
+// Open reads the model of the package given by its path.
+func Open(path string) (*Package, error) {
+	reader, err := archive.MakeZipFile(path, "")
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return opc.Read(reader)
+}
+
+// OpenReader reads the model of the package held by the given reader (size is
+// the length of the package in bytes). The reader is only used during the
+// call.
+func OpenReader(readerAt io.ReaderAt, size int64) (*Package, error) {
+	reader, err := archive.MakeZipFileFromReaderAt(readerAt, size, "")
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return opc.Read(reader)
+}
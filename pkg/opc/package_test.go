@@ -0,0 +1,140 @@
+package opc
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"github.com/nagygr/ooxml2txt/internal/archive"
+	"reflect"
+	"testing"
+)
+
+const (
+	slideContentType           = "application/vnd.openxmlformats-officedocument.presentationml.slide+xml"
+	notesSlideRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+)
+
+func TestReadingParts(t *testing.T) {
+	pkg, err := Open("../../test_data/notes.pptx")
+	if err != nil {
+		t.Fatalf("Failed to open the package: %s", err.Error())
+	}
+
+	if len(pkg.Parts()) != 8 {
+		t.Errorf("Expected 8 parts, found: %d", len(pkg.Parts()))
+	}
+
+	part, found := pkg.Part("/PPT/Slides/Slide1.xml")
+	if !found || part.Name != "ppt/slides/slide1.xml" || part.ContentType != slideContentType {
+		t.Errorf("Expected the part of the first slide, found: %v", part)
+	}
+
+	if part, found := pkg.Part("ppt/_rels/presentation.xml.rels"); !found ||
+		part.ContentType != "application/vnd.openxmlformats-package.relationships+xml" {
+		t.Errorf("Expected the relationships part with its default content type, found: %v", part)
+	}
+
+	if _, found := pkg.Part("[Content_Types].xml"); found {
+		t.Errorf("Expected [Content_Types].xml not to be a part")
+	}
+
+	var names []string
+	for _, p := range pkg.PartsByContentType(slideContentType) {
+		names = append(names, p.Name)
+	}
+
+	if expected := []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the slide parts to be: %v, were: %v", expected, names)
+	}
+}
+
+func TestReadingRelationships(t *testing.T) {
+	pkg, err := Open("../../test_data/notes.pptx")
+	if err != nil {
+		t.Fatalf("Failed to open the package: %s", err.Error())
+	}
+
+	mainPart, err := pkg.MainPart()
+	if err != nil || mainPart.Name != "ppt/presentation.xml" {
+		t.Fatalf("Expected the main part to be ppt/presentation.xml, was: %v (%v)", mainPart, err)
+	}
+
+	relationships, err := pkg.Relationships(mainPart.Name)
+	if err != nil || len(relationships) != 2 {
+		t.Fatalf("Expected 2 relationships of the presentation, found: %v (%v)", relationships, err)
+	}
+
+	if r := relationships[0]; r.Source != "ppt/presentation.xml" || r.Id != "rId11" || r.Target != "slides/slide1.xml" {
+		t.Errorf("Unexpected first relationship: %v", r)
+	}
+
+	for _, c := range []struct {
+		part     string
+		id       string
+		expected string
+	}{
+		{"ppt/presentation.xml", "rId12", "ppt/slides/slide2.xml"},
+		{"ppt/slides/slide1.xml", "rId1", "ppt/notesSlides/notesSlide1.xml"},
+		{"ppt/notesSlides/notesSlide1.xml", "rId1", "ppt/slides/slide1.xml"},
+		{"", "rId1", "ppt/presentation.xml"},
+	} {
+		if target, found := pkg.TargetOf(c.part, c.id); !found || target != c.expected {
+			t.Errorf("Expected the target of %s in %s to be: %s, was: %s", c.id, c.part, c.expected, target)
+		}
+	}
+
+	if _, found := pkg.TargetOf("ppt/presentation.xml", "rId1"); found {
+		t.Errorf("Expected no relationship with id rId1 in the presentation")
+	}
+
+	if notes := pkg.RelationshipsOfType("ppt/slides/slide1.xml", notesSlideRelationshipType); len(notes) != 1 {
+		t.Errorf("Expected one notes slide of the first slide, found: %v", notes)
+	}
+
+	if relationships, err := pkg.Relationships("ppt/slides/slide2.xml"); err != nil || len(relationships) != 0 {
+		t.Errorf("Expected no relationships of the second slide, found: %v (%v)", relationships, err)
+	}
+}
+
+func TestReadingExternalRelationships(t *testing.T) {
+	pkg, err := Open("../../test_data/links.docx")
+	if err != nil {
+		t.Fatalf("Failed to open the package: %s", err.Error())
+	}
+
+	r, found := pkg.Relationship("word/document.xml", "rId1")
+	if !found || !r.External || r.TargetPart() != "" {
+		t.Errorf("Expected an external relationship without a target part, found: %v", r)
+	}
+
+	target, found := pkg.TargetOf("word/document.xml", "rId1")
+	if expected := "https://en.wikipedia.org/wiki/Aarhus"; !found || target != expected {
+		t.Errorf("Expected the target to be: %s, was: %s", expected, target)
+	}
+}
+
+func TestReadingNonOoxmlPackage(t *testing.T) {
+	var data bytes.Buffer
+
+	writer := zip.NewWriter(&data)
+	if file, err := writer.Create("readme.txt"); err == nil {
+		file.Write([]byte("Not a document"))
+	}
+	writer.Close()
+
+	_, err := OpenReader(bytes.NewReader(data.Bytes()), int64(data.Len()))
+	if !errors.Is(err, archive.ErrNotOoxml) {
+		t.Errorf("Expected an error of the ErrNotOoxml kind, got: %v", err)
+	}
+}
+
+func TestMissingMainPart(t *testing.T) {
+	pkg, err := Open("../../test_data/broken_missing_document_xml.docx")
+	if err != nil {
+		t.Fatalf("Failed to open the package: %s", err.Error())
+	}
+
+	if _, err := pkg.MainPart(); !errors.Is(err, archive.ErrMissingPart) {
+		t.Errorf("Expected an error of the ErrMissingPart kind, got: %v", err)
+	}
+}